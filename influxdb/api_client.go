@@ -0,0 +1,113 @@
+package influxdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiClient is a minimal client for the /api/v2 HTTP API of InfluxDB 2.x,
+// authenticating with an API token.
+type apiClient struct {
+	url        url.URL
+	token      string
+	org        string
+	httpClient *http.Client
+}
+
+// apiError is the error document returned by the /api/v2 endpoints.
+type apiError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("received status code %d from server", e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status code %d)", e.Message, e.StatusCode)
+}
+
+func newAPIClient(u url.URL, token, org string, httpClient *http.Client) *apiClient {
+	return &apiClient{
+		url:        u,
+		token:      token,
+		org:        org,
+		httpClient: httpClient,
+	}
+}
+
+// isNotFound reports whether err is the API telling us an object is gone.
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends in as the JSON body of a request to path, relative to /api/v2,
+// and decodes the JSON response into out. Either may be nil.
+func (c *apiClient) do(method, path string, params url.Values, in, out interface{}) error {
+	if c.token == "" {
+		return fmt.Errorf("the provider token must be set to manage InfluxDB 2.x resources")
+	}
+
+	u := c.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2" + path
+	u.RawQuery = params.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// orgID returns id if it is set, and otherwise the ID of the organization
+// configured on the provider.
+func (c *apiClient) orgID(id string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+	if c.org == "" {
+		return "", fmt.Errorf("org_id must be set when the provider has no org configured")
+	}
+
+	org, err := findOrganization(c, c.org)
+	if err != nil {
+		return "", err
+	}
+	return org.ID, nil
+}
@@ -0,0 +1,468 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAPIToken = "terraform-test-token"
+	testAPIOrg   = "terraform-org"
+)
+
+// fakeAPI is an in-process stand-in for the /api/v2 endpoints of an InfluxDB
+// 2.x server. It stores every object as a JSON document in a collection named
+// after the first path segment, e.g. /api/v2/buckets.
+type fakeAPI struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	nextID      int
+	collections map[string]*fakeCollection
+	defaultOrg  string
+
+	// passwords holds the password set for each user ID.
+	passwords map[string]string
+	// roles holds the user IDs in the members and owners of each
+	// organization, keyed by e.g. "<org_id>/members".
+	roles map[string][]string
+}
+
+type fakeCollection struct {
+	// listKey is the property holding the documents in list responses.
+	listKey string
+	// filters maps the query parameters accepted when listing to the
+	// document properties they match.
+	filters map[string]string
+	// normalize fills in the server-generated properties of a created or
+	// updated document.
+	normalize func(doc map[string]interface{})
+	// contentKey, when set, is the property wrapping single documents in
+	// responses to GET and PATCH.
+	contentKey string
+	// scope is the query parameter every request for a single document must
+	// carry, matching the document property of the same name.
+	scope string
+
+	docs  map[string]map[string]interface{}
+	order []string
+}
+
+func newFakeAPI() *fakeAPI {
+	api := &fakeAPI{
+		passwords: make(map[string]string),
+		roles:     make(map[string][]string),
+		collections: map[string]*fakeCollection{
+			"orgs": {
+				listKey: "orgs",
+				filters: map[string]string{"org": "name", "orgID": "id"},
+			},
+			"buckets": {
+				listKey: "buckets",
+				filters: map[string]string{"name": "name", "orgID": "orgID"},
+			},
+			"authorizations": {
+				listKey: "authorizations",
+				filters: map[string]string{"orgID": "orgID"},
+			},
+			"users": {
+				listKey: "users",
+				filters: map[string]string{"name": "name", "id": "id"},
+			},
+			"tasks": {
+				listKey: "tasks",
+				filters: map[string]string{"orgID": "orgID", "name": "name"},
+			},
+			"dbrps": {
+				listKey:    "content",
+				filters:    map[string]string{"orgID": "orgID", "db": "database", "bucketID": "bucketID"},
+				contentKey: "content",
+				scope:      "orgID",
+			},
+		},
+	}
+	api.collections["authorizations"].normalize = func(doc map[string]interface{}) {
+		if _, ok := doc["token"]; !ok {
+			doc["token"] = fmt.Sprintf("token-%s", doc["id"])
+		}
+		if _, ok := doc["status"]; !ok {
+			doc["status"] = "active"
+		}
+		// Permissions on resources of the organization of the
+		// authorization are reported with its ID.
+		permissions, _ := doc["permissions"].([]interface{})
+		for _, p := range permissions {
+			resource, _ := p.(map[string]interface{})["resource"].(map[string]interface{})
+			if _, ok := resource["orgID"]; resource != nil && !ok {
+				resource["orgID"] = doc["orgID"]
+			}
+		}
+	}
+	api.collections["buckets"].normalize = normalizeFakeBucket
+	api.collections["tasks"].normalize = normalizeFakeTask
+	api.collections["users"].normalize = func(doc map[string]interface{}) {
+		if _, ok := doc["status"]; !ok {
+			doc["status"] = "active"
+		}
+	}
+	for _, c := range api.collections {
+		c.docs = make(map[string]map[string]interface{})
+	}
+
+	org := api.insert("orgs", map[string]interface{}{"name": testAPIOrg, "description": ""})
+	api.defaultOrg = org["id"].(string)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v2/", api.serveAPI)
+
+	api.Server = httptest.NewServer(mux)
+
+	return api
+}
+
+// providerConfig returns a provider block pointing at the fake server.
+func (api *fakeAPI) providerConfig() string {
+	return fmt.Sprintf(`
+provider "influxdb" {
+  url   = "%s"
+  token = "%s"
+  org   = "%s"
+}
+`, api.URL, testAPIToken, testAPIOrg)
+}
+
+func (api *fakeAPI) insert(collection string, doc map[string]interface{}) map[string]interface{} {
+	c := api.collections[collection]
+
+	api.nextID++
+	id := fmt.Sprintf("%016x", api.nextID)
+	doc["id"] = id
+	if c.normalize != nil {
+		c.normalize(doc)
+	}
+
+	c.docs[id] = doc
+	c.order = append(c.order, id)
+	return doc
+}
+
+// get returns a copy of a stored document, or nil if it doesn't exist.
+func (api *fakeAPI) get(collection, id string) map[string]interface{} {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	doc, ok := api.collections[collection].docs[id]
+	if !ok {
+		return nil
+	}
+	result := make(map[string]interface{})
+	for k, v := range doc {
+		result[k] = v
+	}
+	return result
+}
+
+// updateAll changes every stored document of a collection, as if they were
+// edited outside of Terraform.
+func (api *fakeAPI) updateAll(collection string, update map[string]interface{}) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	c := api.collections[collection]
+	for _, doc := range c.docs {
+		for k, v := range update {
+			doc[k] = v
+		}
+		if c.normalize != nil {
+			c.normalize(doc)
+		}
+	}
+}
+
+func (api *fakeAPI) serveAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Token "+testAPIToken {
+		writeAPIError(w, http.StatusUnauthorized, "unauthorized access")
+		return
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v2/"), "/"), "/")
+	c, ok := api.collections[parts[0]]
+	if ok && len(parts) > 2 {
+		if _, ok := c.docs[parts[1]]; !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Sprintf("%s not found", parts[0]))
+			return
+		}
+		api.serveSubresource(w, r, parts)
+		return
+	}
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "path not found")
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case "GET":
+			api.list(w, c, r.URL.Query())
+		case "POST":
+			var doc map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+				writeAPIError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusCreated, api.insert(parts[0], doc))
+		default:
+			writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	if c.scope != "" && r.URL.Query().Get(c.scope) == "" {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("%s is required", c.scope))
+		return
+	}
+
+	doc, ok := c.docs[parts[1]]
+	if !ok || (c.scope != "" && doc[c.scope] != r.URL.Query().Get(c.scope)) {
+		writeAPIError(w, http.StatusNotFound, fmt.Sprintf("%s not found", parts[0]))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		writeJSON(w, http.StatusOK, c.content(doc))
+	case "PATCH":
+		var update map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for k, v := range update {
+			doc[k] = v
+		}
+		if c.normalize != nil {
+			c.normalize(doc)
+		}
+		writeJSON(w, http.StatusOK, c.content(doc))
+	case "DELETE":
+		delete(c.docs, parts[1])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// serveSubresource handles the password of users, e.g.
+// /api/v2/users/{id}/password, and the members and owners of organizations,
+// e.g. /api/v2/orgs/{id}/members/{userID}.
+func (api *fakeAPI) serveSubresource(w http.ResponseWriter, r *http.Request, parts []string) {
+	var body map[string]string
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	switch {
+	case parts[0] == "users" && parts[2] == "password" && len(parts) == 3 && r.Method == "POST":
+		api.passwords[parts[1]] = body["password"]
+		w.WriteHeader(http.StatusNoContent)
+
+	case parts[0] == "orgs" && (parts[2] == "members" || parts[2] == "owners"):
+		key := parts[1] + "/" + parts[2]
+		role := strings.TrimSuffix(parts[2], "s")
+
+		switch {
+		case len(parts) == 3 && r.Method == "GET":
+			var users = []map[string]interface{}{}
+			for _, id := range api.roles[key] {
+				if user, ok := api.collections["users"].docs[id]; ok {
+					users = append(users, map[string]interface{}{"id": id, "name": user["name"], "role": role})
+				}
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"users": users})
+		case len(parts) == 3 && r.Method == "POST":
+			user, ok := api.collections["users"].docs[body["id"]]
+			if !ok {
+				writeAPIError(w, http.StatusNotFound, "user not found")
+				return
+			}
+			api.roles[key] = append(api.roles[key], body["id"])
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"id": body["id"], "name": user["name"], "role": role})
+		case len(parts) == 4 && r.Method == "DELETE":
+			var ids []string
+			for _, id := range api.roles[key] {
+				if id != parts[3] {
+					ids = append(ids, id)
+				}
+			}
+			api.roles[key] = ids
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+	default:
+		writeAPIError(w, http.StatusNotFound, "path not found")
+	}
+}
+
+// hasRole reports whether a user is in the members or owners of an
+// organization.
+func (api *fakeAPI) hasRole(orgID, endpoint, userID string) bool {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for _, id := range api.roles[orgID+"/"+endpoint] {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// password returns the password set for a user.
+func (api *fakeAPI) password(userID string) string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	return api.passwords[userID]
+}
+
+func (api *fakeAPI) list(w http.ResponseWriter, c *fakeCollection, params url.Values) {
+	var docs = []map[string]interface{}{}
+	for _, id := range c.order {
+		doc, ok := c.docs[id]
+		if !ok {
+			continue
+		}
+		matches := true
+		for param, property := range c.filters {
+			if v := params.Get(param); v != "" && doc[property] != v {
+				matches = false
+			}
+		}
+		if matches {
+			docs = append(docs, doc)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{c.listKey: docs})
+}
+
+// content wraps doc the way the collection's single document responses are.
+func (c *fakeCollection) content(doc map[string]interface{}) interface{} {
+	if c.contentKey == "" {
+		return doc
+	}
+	return map[string]interface{}{c.contentKey: doc}
+}
+
+// normalizeFakeBucket fills in the schema type and shard group durations the
+// way InfluxDB does when they aren't set.
+func normalizeFakeBucket(doc map[string]interface{}) {
+	if _, ok := doc["schemaType"]; !ok {
+		doc["schemaType"] = "implicit"
+	}
+
+	rules, _ := doc["retentionRules"].([]interface{})
+	for _, v := range rules {
+		rule := v.(map[string]interface{})
+		if shard, _ := rule["shardGroupDurationSeconds"].(float64); shard != 0 {
+			continue
+		}
+		every, _ := rule["everySeconds"].(float64)
+		switch {
+		case every > 0 && every < 2*24*3600:
+			rule["shardGroupDurationSeconds"] = 3600
+		case every > 0 && every < 180*24*3600:
+			rule["shardGroupDurationSeconds"] = 24 * 3600
+		default:
+			rule["shardGroupDurationSeconds"] = 7 * 24 * 3600
+		}
+	}
+}
+
+var fakeTaskOptionRegexp = regexp.MustCompile(`(\w+):\s*("(?:[^"\\]|\\.)*"|[0-9a-zµ]+)`)
+
+// normalizeFakeTask derives the schedule of a task from the task option of
+// its script, the way InfluxDB does.
+func normalizeFakeTask(doc map[string]interface{}) {
+	if _, ok := doc["status"]; !ok {
+		doc["status"] = "active"
+	}
+
+	for _, k := range []string{"name", "every", "cron", "offset"} {
+		delete(doc, k)
+	}
+	option := taskOptionRegexp.FindString(doc["flux"].(string))
+	for _, m := range fakeTaskOptionRegexp.FindAllStringSubmatch(option, -1) {
+		if v, err := strconv.Unquote(m[2]); err == nil {
+			doc[m[1]] = v
+		} else {
+			doc[m[1]] = m[2]
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"code":    strings.ToLower(http.StatusText(status)),
+		"message": message,
+	})
+}
+
+func TestAPIClient(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+	u, _ := url.Parse(api.URL)
+
+	c := newAPIClient(*u, testAPIToken, testAPIOrg, http.DefaultClient)
+	orgID, err := c.orgID("")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if orgID != api.defaultOrg {
+		t.Fatalf("expected the provider org to resolve to %q, got %q", api.defaultOrg, orgID)
+	}
+
+	var b bucket
+	err = c.do("GET", "/buckets/0000000000000000", nil, nil, &b)
+	if !isNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	c = newAPIClient(*u, "wrong-token", testAPIOrg, http.DefaultClient)
+	err = c.do("GET", "/orgs", nil, nil, nil)
+	if apiErr, ok := err.(*apiError); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "unauthorized access") {
+		t.Fatalf("expected the error to include the server's message, got %q", err)
+	}
+
+	c = newAPIClient(*u, "", testAPIOrg, http.DefaultClient)
+	if err := c.do("GET", "/orgs", nil, nil, nil); err == nil {
+		t.Fatalf("expected an error without a token")
+	}
+}
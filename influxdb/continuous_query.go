@@ -2,16 +2,22 @@ package influxdb
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/influxdata/influxdb/client"
 )
 
 func resourceContinuousQuery() *schema.Resource {
 	return &schema.Resource{
 		Create: createContinuousQuery,
 		Read:   readContinuousQuery,
+		Update: updateContinuousQuery,
 		Delete: deleteContinuousQuery,
+		Importer: &schema.ResourceImporter{
+			State: importContinuousQuery,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -27,42 +33,54 @@ func resourceContinuousQuery() *schema.Resource {
 			"query": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeQuery(old) == normalizeQuery(new)
+				},
 			},
 			"resample": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
-				Default:  "",
+				Type:          schema.TypeString,
+				Optional:      true,
+				Default:       "",
+				Deprecated:    "Use resample_every and resample_for instead",
+				ConflictsWith: []string{"resample_every", "resample_for"},
+				ValidateFunc:  validateResample,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeResample(old) == normalizeResample(new)
+				},
+			},
+			"resample_every": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "",
+				ConflictsWith:    []string{"resample"},
+				ValidateFunc:     validateDuration,
+				DiffSuppressFunc: suppressEquivalentDurations,
+			},
+			"resample_for": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "",
+				ConflictsWith:    []string{"resample"},
+				ValidateFunc:     validateDuration,
+				DiffSuppressFunc: suppressEquivalentDurations,
 			},
 		},
 	}
 }
 
 func createContinuousQuery(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 
 	name := d.Get("name").(string)
 	database := d.Get("database").(string)
-	resample := d.Get("resample").(string)
+	resample := resampleClause(d.Get("resample").(string), d.Get("resample_every").(string), d.Get("resample_for").(string))
 
-	var queryStr string
-	if resample == "" {
-		queryStr = fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN %s END", name, quoteIdentifier(database), d.Get("query").(string))
-	} else {
-		queryStr = fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s RESAMPLE %s BEGIN %s END", name, quoteIdentifier(database), resample, d.Get("query").(string))
-	}
-
-	query := client.Query{
-		Command: queryStr,
-	}
-
-	resp, err := conn.Query(query)
+	statement, err := continuousQueryStatement(name, database, resample, d.Get("query").(string))
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
+	if err := exec(conn, statement); err != nil {
+		return err
 	}
 
 	d.Set("name", name)
@@ -73,58 +91,412 @@ func createContinuousQuery(d *schema.ResourceData, meta interface{}) error {
 	return readContinuousQuery(d, meta)
 }
 
+func continuousQueryStatement(name, database, resample, query string) (string, error) {
+	if resample == "" {
+		return fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN %s END", quoteIdentifier(name), quoteIdentifier(database), query), nil
+	}
+	if err := checkResample(resample); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s RESAMPLE %s BEGIN %s END", quoteIdentifier(name), quoteIdentifier(database), resample, query), nil
+}
+
+// resampleClause builds the body of a RESAMPLE clause, preferring the
+// deprecated free-form resample attribute when it is set.
+func resampleClause(resample, every, forDuration string) string {
+	if resample != "" {
+		return resample
+	}
+
+	var clause []string
+	if every != "" {
+		clause = append(clause, "EVERY "+every)
+	}
+	if forDuration != "" {
+		clause = append(clause, "FOR "+forDuration)
+	}
+	return strings.Join(clause, " ")
+}
+
+// parseResample splits the body of a RESAMPLE clause into its EVERY and FOR
+// durations.
+func parseResample(resample string) (every, forDuration string) {
+	fields := strings.Fields(resample)
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch strings.ToUpper(fields[i]) {
+		case "EVERY":
+			every = fields[i+1]
+		case "FOR":
+			forDuration = fields[i+1]
+		}
+	}
+	return every, forDuration
+}
+
+// checkResample makes sure the body of a RESAMPLE clause is made of EVERY
+// and FOR durations only, as it is pasted into the statement.
+func checkResample(resample string) error {
+	every, forDuration := parseResample(resample)
+	if (every == "" && forDuration == "") || normalizeResample(resampleClause("", every, forDuration)) != normalizeResample(resample) {
+		return fmt.Errorf("invalid RESAMPLE clause %q", resample)
+	}
+	if err := checkDuration(every); err != nil {
+		return err
+	}
+	return checkDuration(forDuration)
+}
+
+func validateResample(v interface{}, k string) (ws []string, errors []error) {
+	if v.(string) == "" {
+		return
+	}
+	if err := checkResample(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a RESAMPLE clause such as EVERY 30m FOR 90m: %s", k, err))
+	}
+	return
+}
+
 func readContinuousQuery(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 	name := d.Get("name").(string)
 	database := d.Get("database").(string)
 
 	// InfluxDB doesn't have a command to check the existence of a single
 	// ContinuousQuery, so we instead must read the list of all ContinuousQuerys and see
 	// if ours is present in it.
-	query := client.Query{
-		Command: "SHOW CONTINUOUS QUERIES",
+	queries, err := listContinuousQueries(conn, database)
+	if err != nil {
+		return err
 	}
 
-	resp, err := conn.Query(query)
+	if statement, ok := queries[name]; ok {
+		return readContinuousQueryDefinition(d, conn, database, statement)
+	}
+
+	// If we fell out here then we didn't find our ContinuousQuery in the list.
+	d.SetId("")
+
+	return nil
+}
+
+// listContinuousQueries returns the statements of the continuous queries of
+// a database, keyed by name.
+func listContinuousQueries(conn *queryClient, database string) (map[string]string, error) {
+	series, err := show(conn, "SHOW CONTINUOUS QUERIES")
+	if err != nil {
+		return nil, err
+	}
+
+	queries := map[string]string{}
+	for _, s := range series {
+		if s.name != database {
+			continue
+		}
+		for _, row := range s.rows {
+			name, err := row.string("name")
+			if err != nil {
+				return nil, err
+			}
+			if queries[name], err = row.string("query"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return queries, nil
+}
+
+// readContinuousQueryDefinition updates query and resample from the
+// statement reported by SHOW CONTINUOUS QUERIES, keeping the configured
+// spelling whenever it is equivalent to what the server reports.
+func readContinuousQueryDefinition(d *schema.ResourceData, conn *queryClient, database, statement string) error {
+	query, resample, err := parseContinuousQuery(statement)
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
+
+	// InfluxDB qualifies every measurement with the database and the
+	// retention policy that was the default when the query was created.
+	policies, err := listRetentionPolicies(conn, database)
+	if err != nil {
+		return err
+	}
+	current := d.Get("query").(string)
+	for _, policy := range policies {
+		if policy.isDefault {
+			query = unqualifyMeasurements(query, database, policy.name)
+			current = unqualifyMeasurements(current, database, policy.name)
+		}
+	}
+
+	if normalizeQuery(query) != normalizeQuery(current) {
+		d.Set("query", query)
+	}
+	if legacy := d.Get("resample").(string); legacy != "" {
+		if normalizeResample(resample) != normalizeResample(legacy) {
+			d.Set("resample", resample)
+		}
+		return nil
+	}
+
+	every, forDuration := parseResample(resample)
+	if !suppressEquivalentDurations("", every, d.Get("resample_every").(string), d) {
+		d.Set("resample_every", every)
+	}
+	if !suppressEquivalentDurations("", forDuration, d.Get("resample_for").(string), d) {
+		d.Set("resample_for", forDuration)
+	}
+
+	return nil
+}
+
+// parseContinuousQuery splits a CREATE CONTINUOUS QUERY statement into the
+// SELECT statement between BEGIN and END and the body of its RESAMPLE clause.
+func parseContinuousQuery(statement string) (query, resample string, err error) {
+	begin := strings.Index(statement, " BEGIN ")
+	end := strings.LastIndex(statement, " END")
+	if begin < 0 || end < begin {
+		return "", "", fmt.Errorf("unable to parse continuous query %q", statement)
+	}
+
+	query = strings.TrimSpace(statement[begin+len(" BEGIN ") : end])
+	if i := strings.Index(statement[:begin], " RESAMPLE "); i >= 0 {
+		resample = strings.TrimSpace(statement[i+len(" RESAMPLE ") : begin])
 	}
 
-	for _, series := range resp.Results[0].Series {
-		if series.Name == database {
-			for _, result := range series.Values {
-				if result[0].(string) == name {
-					return nil
+	return query, resample, nil
+}
+
+// unqualifyMeasurements strips the "<database>.<policy>." prefix InfluxDB
+// adds to measurements when it stores a continuous query.
+func unqualifyMeasurements(query, database, policy string) string {
+	prefix := fmt.Sprintf(`(^|[^\w."])(?:%s|%s)\.(?:%s|%s)\.`,
+		regexp.QuoteMeta(quoteIdentifier(database)), regexp.QuoteMeta(database),
+		regexp.QuoteMeta(quoteIdentifier(policy)), regexp.QuoteMeta(policy))
+	return regexp.MustCompile(prefix).ReplaceAllString(query, "$1")
+}
+
+var (
+	influxqlKeywords = map[string]bool{
+		"ALL": true, "AND": true, "AS": true, "ASC": true, "BY": true,
+		"DESC": true, "FROM": true, "GROUP": true, "INTO": true, "LIMIT": true,
+		"OFFSET": true, "OR": true, "ORDER": true, "SELECT": true,
+		"SLIMIT": true, "SOFFSET": true, "TZ": true, "WHERE": true,
+	}
+	bareIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	durationRegexp       = regexp.MustCompile(`^([0-9]+(ns|u|µ|ms|s|m|h|d|w))+$`)
+)
+
+type queryTokenKind int
+
+const (
+	wordToken queryTokenKind = iota
+	quotedToken
+	punctuationToken
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+}
+
+// normalizeQuery rewrites a SELECT statement the way InfluxDB does when it
+// stores a continuous query: keywords are upper cased, function names are
+// lower cased, durations use the largest unit that divides them evenly,
+// identifiers are only quoted when they need to be and whitespace is
+// canonical. Two queries that normalize to the same string are equivalent.
+func normalizeQuery(query string) string {
+	tokens := tokenizeQuery(query)
+
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && spaceBetween(tokens[i-1], token) {
+			b.WriteByte(' ')
+		}
+		if isFunctionName(tokens, i) {
+			token.text = strings.ToLower(token.text)
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+func tokenizeQuery(query string) []queryToken {
+	var tokens []queryToken
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '\'' || r == '"' || (r == '/' && regexAllowedAfter(tokens)):
+			// Scan a string literal, quoted identifier or regular
+			// expression up to its unescaped closing delimiter.
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
 				}
+				j++
+			}
+			if j < len(runes) {
+				j++
+			}
+			text := string(runes[i:j])
+			if r == '"' && len(text) > 1 && strings.HasSuffix(text, `"`) {
+				if ident := text[1 : len(text)-1]; bareIdentifierRegexp.MatchString(ident) && !influxqlKeywords[strings.ToUpper(ident)] {
+					tokens = append(tokens, queryToken{wordToken, ident})
+					i = j
+					continue
+				}
+			}
+			tokens = append(tokens, queryToken{quotedToken, text})
+			i = j
+
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			text := string(runes[i:j])
+			if upper := strings.ToUpper(text); influxqlKeywords[upper] {
+				text = upper
+			} else if durationRegexp.MatchString(text) {
+				if d, err := parseDuration(text); err == nil {
+					text = formatDuration(d)
+				}
+			}
+			tokens = append(tokens, queryToken{wordToken, text})
+			i = j
+
+		case strings.ContainsRune("<>=!~", r):
+			j := i
+			for j < len(runes) && strings.ContainsRune("<>=!~", runes[j]) {
+				j++
 			}
+			tokens = append(tokens, queryToken{punctuationToken, string(runes[i:j])})
+			i = j
+
+		default:
+			tokens = append(tokens, queryToken{punctuationToken, string(r)})
+			i++
 		}
 	}
 
-	// If we fell out here then we didn't find our ContinuousQuery in the list.
-	d.SetId("")
+	return tokens
+}
 
-	return nil
+// regexAllowedAfter reports whether a "/" following the tokens starts a
+// regular expression rather than being a division.
+func regexAllowedAfter(tokens []queryToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	prev := tokens[len(tokens)-1]
+	switch prev.kind {
+	case punctuationToken:
+		return prev.text != ")"
+	case wordToken:
+		return influxqlKeywords[prev.text]
+	}
+	return false
 }
 
-func deleteContinuousQuery(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
-	name := d.Get("name").(string)
-	database := d.Get("database").(string)
+// isFunctionName reports whether the i-th token names a function, which
+// InfluxDB stores in lower case.
+func isFunctionName(tokens []queryToken, i int) bool {
+	return tokens[i].kind == wordToken && !influxqlKeywords[tokens[i].text] &&
+		i+1 < len(tokens) && tokens[i+1].text == "("
+}
+
+func spaceBetween(prev, next queryToken) bool {
+	if next.kind == punctuationToken && strings.Contains(",).:", next.text) {
+		return false
+	}
+	if prev.kind == punctuationToken && strings.Contains("(.:", prev.text) {
+		return false
+	}
+	// Function calls are written without a space before their arguments.
+	if next.text == "(" && prev.kind == wordToken && !influxqlKeywords[prev.text] {
+		return false
+	}
+	return true
+}
 
-	queryStr := fmt.Sprintf("DROP CONTINUOUS QUERY %s ON %s", name, quoteIdentifier(database))
-	query := client.Query{
-		Command: queryStr,
+// normalizeResample rewrites a RESAMPLE clause with upper case keywords and
+// durations formatted the way InfluxDB formats them.
+func normalizeResample(resample string) string {
+	fields := strings.Fields(resample)
+	for i, field := range fields {
+		if d, err := parseDuration(field); err == nil {
+			fields[i] = formatDuration(d)
+		} else {
+			fields[i] = strings.ToUpper(field)
+		}
 	}
+	return strings.Join(fields, " ")
+}
 
-	resp, err := conn.Query(query)
+func importContinuousQuery(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	database, name, err := parseDatabaseScopedID(d.Id())
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if resp.Err != nil {
-		return resp.Err
+
+	d.Set("database", database)
+	d.Set("name", name)
+	d.SetId(fmt.Sprintf("influxdb-cq:%s", name))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func updateContinuousQuery(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+	name := d.Get("name").(string)
+	database := d.Get("database").(string)
+
+	if d.HasChange("query") || d.HasChange("resample") || d.HasChange("resample_every") || d.HasChange("resample_for") {
+		oldQuery, newQuery := d.GetChange("query")
+		oldResample, newResample := d.GetChange("resample")
+		oldEvery, newEvery := d.GetChange("resample_every")
+		oldFor, newFor := d.GetChange("resample_for")
+
+		// InfluxDB can't alter a continuous query, so drop and recreate it
+		// in a single request to keep the time it doesn't run as short as
+		// possible.
+		statement, err := continuousQueryStatement(name, database, resampleClause(newResample.(string), newEvery.(string), newFor.(string)), newQuery.(string))
+		if err != nil {
+			return err
+		}
+		queryStr := fmt.Sprintf("DROP CONTINUOUS QUERY %s ON %s; %s", quoteIdentifier(name), quoteIdentifier(database), statement)
+
+		if err := exec(conn, queryStr); err != nil {
+			// Put the previous definition back if the new one was rejected.
+			previous, restoreErr := continuousQueryStatement(name, database, resampleClause(oldResample.(string), oldEvery.(string), oldFor.(string)), oldQuery.(string))
+			if restoreErr == nil {
+				restoreErr = exec(conn, previous)
+			}
+			if restoreErr != nil {
+				return fmt.Errorf("%s; additionally failed to restore previous continuous query: %s", err, restoreErr)
+			}
+			return err
+		}
+	}
+
+	return readContinuousQuery(d, meta)
+}
+
+func deleteContinuousQuery(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+	name := d.Get("name").(string)
+	database := d.Get("database").(string)
+
+	if err := exec(conn, fmt.Sprintf("DROP CONTINUOUS QUERY %s ON %s", quoteIdentifier(name), quoteIdentifier(database))); err != nil {
+		// The continuous query is gone along with its database.
+		if !strings.Contains(err.Error(), "database not found") {
+			return err
+		}
 	}
 
 	d.SetId("")
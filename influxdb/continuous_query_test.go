@@ -2,11 +2,11 @@ package influxdb
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/influxdata/influxdb/client"
 )
 
 func TestAccInfluxDBContiuousQuery(t *testing.T) {
@@ -33,12 +33,328 @@ func TestAccInfluxDBContiuousQuery(t *testing.T) {
 					resource.TestCheckResourceAttr(
 						"influxdb_continuous_query.minnie_resample", "resample", "EVERY 30m FOR 90m",
 					),
+					testAccCheckContiuousQueryExists("influxdb_continuous_query.minnie_every"),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_every", "30m",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_for", "90m",
+					),
+				),
+			},
+			{
+				Config: testAccContiuousQueryUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckContiuousQueryExists("influxdb_continuous_query.minnie"),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie", "query", "SELECT max(mouse) INTO max_mouse FROM zoo GROUP BY time(1h)",
+					),
+					testAccCheckContiuousQueryExists("influxdb_continuous_query.minnie_resample"),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_resample", "resample", "EVERY 30m FOR 2h",
+					),
+					testAccCheckContiuousQueryExists("influxdb_continuous_query.minnie_every"),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_every", "",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_for", "120m",
+					),
+				),
+			},
+			{
+				ResourceName:      "influxdb_continuous_query.minnie_resample",
+				ImportState:       true,
+				ImportStateId:     "terraform-test/minnie_resample",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestInfluxDBContinuousQuery(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckFakeDatabaseDestroyed(fake, "terraform-test"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccContiuousQueryConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeContinuousQuery(fake, "terraform-test", "minnie",
+						`CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT min(mouse) INTO "terraform-test".autogen.min_mouse FROM "terraform-test".autogen.zoo GROUP BY time(30m) END`),
+					testCheckFakeContinuousQuery(fake, "terraform-test", "minnie_resample",
+						`CREATE CONTINUOUS QUERY minnie_resample ON "terraform-test" RESAMPLE EVERY 30m FOR 90m BEGIN SELECT min(mouse) INTO "terraform-test".autogen.min_mouse_resampled FROM "terraform-test".autogen.zoo GROUP BY time(30m) END`),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie", "query", "SELECT min(mouse) INTO min_mouse FROM zoo GROUP BY time(30m)",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_for", "90m",
+					),
+				),
+			},
+			{
+				Config: fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeContinuousQuery(fake, "terraform-test", "minnie",
+						`CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT max(mouse) INTO "terraform-test".autogen.max_mouse FROM "terraform-test".autogen.zoo GROUP BY time(1h) END`),
+					testCheckFakeContinuousQuery(fake, "terraform-test", "minnie_every",
+						`CREATE CONTINUOUS QUERY minnie_every ON "terraform-test" RESAMPLE FOR 2h BEGIN SELECT min(mouse) INTO "terraform-test".autogen.min_mouse_every FROM "terraform-test".autogen.zoo GROUP BY time(30m) END`),
+					resource.TestCheckResourceAttr(
+						"influxdb_continuous_query.minnie_every", "resample_for", "120m",
+					),
+				),
+			},
+			{
+				Config:            fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				ResourceName:      "influxdb_continuous_query.minnie",
+				ImportState:       true,
+				ImportStateId:     "terraform-test/minnie",
+				ImportStateVerify: true,
+			},
+			{
+				PreConfig: func() {
+					fake.run(t, `DROP CONTINUOUS QUERY minnie ON "terraform-test"; CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT max(mouse) INTO max_mouse FROM zoo GROUP BY time(2h) END`)
+				},
+				Config:             fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig:          func() { fake.run(t, `DROP CONTINUOUS QUERY minnie_resample ON "terraform-test"`) },
+				Config:             fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeContinuousQuery(fake, "terraform-test", "minnie",
+						`CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT max(mouse) INTO "terraform-test".autogen.max_mouse FROM "terraform-test".autogen.zoo GROUP BY time(1h) END`),
+					testAccCheckContiuousQueryExists("influxdb_continuous_query.minnie_resample"),
+				),
+			},
+		},
+	})
+}
+
+func TestInfluxDBContinuousQuery_errors(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccContiuousQueryConfig,
+			},
+			{
+				// Neither the new definition nor the previous one can be
+				// created, so the continuous query is gone.
+				PreConfig: func() { fake.fail(`^CREATE CONTINUOUS QUERY minnie ON`, "timeout") },
+				Config:    fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				ExpectError: regexp.MustCompile(
+					`timeout; additionally failed to restore previous continuous query: timeout`,
 				),
 			},
+			{
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig() + testAccContiuousQueryUpdateConfig,
+				Check: testCheckFakeContinuousQuery(fake, "terraform-test", "minnie",
+					`CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT max(mouse) INTO "terraform-test".autogen.max_mouse FROM "terraform-test".autogen.zoo GROUP BY time(1h) END`),
+			},
+			{
+				PreConfig:   func() { fake.fail(`^DROP CONTINUOUS QUERY`, "timeout") },
+				Config:      fake.providerConfig(),
+				ExpectError: regexp.MustCompile(`timeout`),
+			},
+			{
+				// The continuous queries are still in the state, so they
+				// are dropped again along with their database.
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig(),
+				Check:     testCheckFakeDatabaseDestroyed(fake, "terraform-test"),
+			},
 		},
 	})
 }
 
+// testCheckFakeContinuousQuery checks the statement the fake server stores
+// for a continuous query.
+func testCheckFakeContinuousQuery(fake *fakeInfluxQL, database, name, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		db := fake.database(database)
+		if db == nil {
+			return fmt.Errorf("Database %q does not exist", database)
+		}
+		for _, cq := range db.queries {
+			if cq.name == name {
+				if cq.query != expected {
+					return fmt.Errorf("expected continuous query %q to be %s, got %s", name, expected, cq.query)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("ContiuousQuery %q does not exist", name)
+	}
+}
+
+func TestParseContinuousQuery(t *testing.T) {
+	cases := []struct {
+		statement string
+		query     string
+		resample  string
+	}{
+		{
+			statement: `CREATE CONTINUOUS QUERY minnie ON "terraform-test" BEGIN SELECT min(mouse) INTO "terraform-test".autogen.min_mouse FROM "terraform-test".autogen.zoo GROUP BY time(30m) END`,
+			query:     `SELECT min(mouse) INTO "terraform-test".autogen.min_mouse FROM "terraform-test".autogen.zoo GROUP BY time(30m)`,
+		},
+		{
+			statement: `CREATE CONTINUOUS QUERY minnie_resample ON telegraf RESAMPLE EVERY 30m FOR 90m BEGIN SELECT min(mouse) INTO telegraf.autogen.min_mouse FROM telegraf.autogen.zoo GROUP BY time(30m) END`,
+			query:     `SELECT min(mouse) INTO telegraf.autogen.min_mouse FROM telegraf.autogen.zoo GROUP BY time(30m)`,
+			resample:  `EVERY 30m FOR 90m`,
+		},
+	}
+
+	for _, c := range cases {
+		query, resample, err := parseContinuousQuery(c.statement)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if query != c.query {
+			t.Errorf("expected query %q, got %q", c.query, query)
+		}
+		if resample != c.resample {
+			t.Errorf("expected resample %q, got %q", c.resample, resample)
+		}
+	}
+
+	if _, _, err := parseContinuousQuery("SELECT 1"); err == nil {
+		t.Fatalf("expected an error parsing an invalid statement")
+	}
+}
+
+func TestUnqualifyMeasurements(t *testing.T) {
+	cases := []struct {
+		query    string
+		database string
+		policy   string
+		expected string
+	}{
+		{
+			query:    `SELECT min(mouse) INTO "terraform-test".autogen.min_mouse FROM "terraform-test".autogen.zoo GROUP BY time(30m)`,
+			database: "terraform-test",
+			policy:   "autogen",
+			expected: `SELECT min(mouse) INTO min_mouse FROM zoo GROUP BY time(30m)`,
+		},
+		{
+			query:    `SELECT mean(usage) INTO telegraf."1week".cpu_1h FROM telegraf.autogen.cpu GROUP BY time(1h)`,
+			database: "telegraf",
+			policy:   "autogen",
+			expected: `SELECT mean(usage) INTO telegraf."1week".cpu_1h FROM cpu GROUP BY time(1h)`,
+		},
+		{
+			query:    `SELECT mean(usage) INTO mytelegraf.autogen.cpu_1h FROM other.autogen.cpu GROUP BY time(1h)`,
+			database: "telegraf",
+			policy:   "autogen",
+			expected: `SELECT mean(usage) INTO mytelegraf.autogen.cpu_1h FROM other.autogen.cpu GROUP BY time(1h)`,
+		},
+	}
+
+	for _, c := range cases {
+		if actual := unqualifyMeasurements(c.query, c.database, c.policy); actual != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, actual)
+		}
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT min(mouse) INTO min_mouse FROM zoo GROUP BY time(30m)",
+			expected: "SELECT min(mouse) INTO min_mouse FROM zoo GROUP BY time(30m)",
+		},
+		{
+			query:    "select  min( \"mouse\" )\n  into \"min_mouse\" from zoo group by time(60m) , *",
+			expected: "SELECT min(mouse) INTO min_mouse FROM zoo GROUP BY time(1h), *",
+		},
+		{
+			query:    `SELECT mean("usage idle") AS "select" INTO "terraform-test".autogen.cpu FROM cpu WHERE host='a  b' AND region=~/us-.*/ GROUP BY time(7d)`,
+			expected: `SELECT mean("usage idle") AS "select" INTO "terraform-test".autogen.cpu FROM cpu WHERE host = 'a  b' AND region =~ /us-.*/ GROUP BY time(1w)`,
+		},
+		{
+			query:    "SELECT sum(bytes)/8 INTO bits FROM /net.*/ GROUP BY time(5m) fill(none)",
+			expected: "SELECT sum(bytes) / 8 INTO bits FROM /net.*/ GROUP BY time(5m) fill(none)",
+		},
+		{
+			query:    "SELECT MEAN(value), \"Max\"(value) INTO cpu_1h FROM cpu WHERE (host = 'a') GROUP BY TIME(1h) FILL(none)",
+			expected: "SELECT mean(value), max(value) INTO cpu_1h FROM cpu WHERE (host = 'a') GROUP BY time(1h) fill(none)",
+		},
+	}
+
+	for _, c := range cases {
+		if actual := normalizeQuery(c.query); actual != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, actual)
+		}
+	}
+}
+
+func TestValidateResample(t *testing.T) {
+	for _, v := range []string{"", "EVERY 30m FOR 90m", "every 1h", "FOR 2h"} {
+		if _, errs := validateResample(v, "resample"); len(errs) != 0 {
+			t.Errorf("expected %q to be valid, got %v", v, errs)
+		}
+	}
+	for _, v := range []string{"30m", "EVERY", "FOR 2h EVERY 1h", "EVERY 1h EVERY 2h", "EVERY 1 hour", "EVERY 1h BEGIN SELECT 1 END; DROP DATABASE telegraf"} {
+		if _, errs := validateResample(v, "resample"); len(errs) == 0 {
+			t.Errorf("expected %q to be invalid", v)
+		}
+	}
+}
+
+func TestParseResample(t *testing.T) {
+	cases := []struct {
+		resample    string
+		every       string
+		forDuration string
+	}{
+		{"EVERY 30m FOR 90m", "30m", "90m"},
+		{"every 1h", "1h", ""},
+		{"FOR 2h", "", "2h"},
+		{"", "", ""},
+	}
+
+	for _, c := range cases {
+		every, forDuration := parseResample(c.resample)
+		if every != c.every || forDuration != c.forDuration {
+			t.Errorf("expected %q to parse as (%q, %q), got (%q, %q)", c.resample, c.every, c.forDuration, every, forDuration)
+		}
+		if c.resample != "" && normalizeResample(resampleClause("", every, forDuration)) != normalizeResample(c.resample) {
+			t.Errorf("expected %q to round-trip, got %q", c.resample, resampleClause("", every, forDuration))
+		}
+	}
+}
+
+func TestNormalizeResample(t *testing.T) {
+	cases := map[string]string{
+		"EVERY 30m FOR 90m":  "EVERY 30m FOR 90m",
+		"every 60m for 120m": "EVERY 1h FOR 2h",
+		"FOR 7d":             "FOR 1w",
+		"":                   "",
+	}
+
+	for resample, expected := range cases {
+		if actual := normalizeResample(resample); actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	}
+}
+
 func testAccCheckContiuousQueryExists(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -50,29 +366,15 @@ func testAccCheckContiuousQueryExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No ContiuousQuery id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: "SHOW CONTINUOUS QUERIES",
-		}
-
-		resp, err := conn.Query(query)
+		queries, err := listContinuousQueries(conn, rs.Primary.Attributes["database"])
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, series := range resp.Results[0].Series {
-			if series.Name == rs.Primary.Attributes["database"] {
-				for _, result := range series.Values {
-					if result[0].(string) == rs.Primary.Attributes["name"] {
-						return nil
-					}
-				}
-			}
+		if _, ok := queries[rs.Primary.Attributes["name"]]; ok {
+			return nil
 		}
 
 		return fmt.Errorf("ContiuousQuery %q does not exist", rs.Primary.Attributes["name"])
@@ -98,4 +400,40 @@ resource "influxdb_continuous_query" "minnie_resample" {
     resample = "EVERY 30m FOR 90m"
 }
 
+resource "influxdb_continuous_query" "minnie_every" {
+    name = "minnie_every"
+    database = "${influxdb_database.test.name}"
+    query = "SELECT min(mouse) INTO min_mouse_every FROM zoo GROUP BY time(30m)"
+    resample_every = "30m"
+    resample_for = "90m"
+}
+
+`
+
+var testAccContiuousQueryUpdateConfig = `
+
+resource "influxdb_database" "test" {
+    name = "terraform-test"
+}
+
+resource "influxdb_continuous_query" "minnie" {
+    name = "minnie"
+    database = "${influxdb_database.test.name}"
+    query = "SELECT max(mouse) INTO max_mouse FROM zoo GROUP BY time(1h)"
+}
+
+resource "influxdb_continuous_query" "minnie_resample" {
+    name = "minnie_resample"
+    database = "${influxdb_database.test.name}"
+    query = "SELECT min(mouse) INTO min_mouse_resampled FROM zoo GROUP BY time(30m)"
+    resample = "EVERY 30m FOR 2h"
+}
+
+resource "influxdb_continuous_query" "minnie_every" {
+    name = "minnie_every"
+    database = "${influxdb_database.test.name}"
+    query = "SELECT min(mouse) INTO min_mouse_every FROM zoo GROUP BY time(30m)"
+    resample_for = "120m"
+}
+
 `
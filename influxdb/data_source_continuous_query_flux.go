@@ -0,0 +1,69 @@
+package influxdb
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceContinuousQueryFlux() *schema.Resource {
+	return &schema.Resource{
+		Read: readContinuousQueryFluxDataSource,
+
+		Schema: map[string]*schema.Schema{
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"retention_policy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "autogen",
+			},
+			"query": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"resample_every": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+			},
+			"resample_for": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+			},
+			"flux": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"every": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"offset": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func readContinuousQueryFluxDataSource(d *schema.ResourceData, meta interface{}) error {
+	task, err := continuousQueryToFlux(
+		d.Get("database").(string),
+		d.Get("retention_policy").(string),
+		d.Get("query").(string),
+		d.Get("resample_every").(string),
+		d.Get("resample_for").(string),
+	)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(hashSum(task.Script))
+	d.Set("flux", task.Script)
+	d.Set("every", task.Every)
+	d.Set("offset", task.Offset)
+
+	return nil
+}
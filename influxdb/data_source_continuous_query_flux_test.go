@@ -0,0 +1,58 @@
+package influxdb
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestInfluxDBContinuousQueryFluxDataSource(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testContinuousQueryFluxDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.influxdb_continuous_query_flux.test", "every", "30m",
+					),
+					resource.TestCheckResourceAttr(
+						"data.influxdb_continuous_query_flux.test", "offset", "",
+					),
+					resource.TestMatchResourceAttr(
+						"data.influxdb_continuous_query_flux.test", "flux",
+						regexp.MustCompile(`from\(bucket: "terraform-test/autogen"\)`),
+					),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "every", "30m"),
+				),
+			},
+			{
+				Config: api.providerConfig() + `
+data "influxdb_continuous_query_flux" "test" {
+  database = "terraform-test"
+  query    = "SELECT value INTO b FROM a GROUP BY time(1h)"
+}
+`,
+				ExpectError: regexp.MustCompile("unsupported continuous query"),
+			},
+		},
+	})
+}
+
+var testContinuousQueryFluxDataSourceConfig = `
+data "influxdb_continuous_query_flux" "test" {
+  database       = "terraform-test"
+  query          = "SELECT mean(value) INTO cpu_1h FROM cpu GROUP BY time(1h), *"
+  resample_every = "30m"
+}
+
+resource "influxdb_task" "test" {
+  name  = "cpu_1h"
+  every = "${data.influxdb_continuous_query_flux.test.every}"
+  flux  = "${data.influxdb_continuous_query_flux.test.flux}"
+}
+`
@@ -0,0 +1,119 @@
+package influxdb
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceDatabases() *schema.Resource {
+	return &schema.Resource{
+		Read: readDatabasesDataSource,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"databases": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"retention_policies": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"duration": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"shard_duration": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"replication": {
+										Type:     schema.TypeInt,
+										Computed: true,
+									},
+									"default": {
+										Type:     schema.TypeBool,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func readDatabasesDataSource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	databases, err := listDatabases(conn)
+	if err != nil {
+		return err
+	}
+
+	var names = []string{}
+	var result = []map[string]interface{}{}
+	for _, database := range databases {
+		if nameRegex != nil && !nameRegex.MatchString(database) {
+			continue
+		}
+
+		policies, err := listRetentionPolicies(conn, database)
+		if err != nil {
+			return err
+		}
+
+		var retentionPolicies = []map[string]interface{}{}
+		for _, policy := range policies {
+			retentionPolicies = append(retentionPolicies, map[string]interface{}{
+				"name":           policy.name,
+				"duration":       normalizeDuration(policy.duration),
+				"shard_duration": normalizeDuration(policy.shardGroupDuration),
+				"replication":    policy.replication,
+				"default":        policy.isDefault,
+			})
+		}
+
+		names = append(names, database)
+		result = append(result, map[string]interface{}{
+			"name":               database,
+			"retention_policies": retentionPolicies,
+		})
+	}
+
+	d.SetId(hashSum(strings.Join(names, ",")))
+	d.Set("names", names)
+	if err := d.Set("databases", result); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInfluxDBDatabasesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDatabasesDataSourceConfig,
+				Check:  testCheckDatabasesDataSource(),
+			},
+		},
+	})
+}
+
+func TestInfluxDBDatabasesDataSource(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testDatabasesDataSourceConfig,
+				Check:  testCheckDatabasesDataSource(),
+			},
+		},
+	})
+}
+
+func testCheckDatabasesDataSource() resource.TestCheckFunc {
+	return resource.ComposeTestCheckFunc(
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "names.#", "1",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "names.0", "terraform-ds-test",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.name", "terraform-ds-test",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.#", "2",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.1.name", "1week",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.1.duration", "1w",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.1.shard_duration", "1h",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.1.replication", "1",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_databases.test", "databases.0.retention_policies.1.default", "true",
+		),
+	)
+}
+
+var testAccDatabasesDataSourceConfig = `
+resource "influxdb_database" "test" {
+	name = "terraform-ds-test"
+	retention_policies {
+		name = "1week"
+		duration = "1w"
+		shardgroupduration = "1h"
+		default = "true"
+	}
+}
+
+resource "influxdb_database" "other" {
+	name = "terraform-other"
+}
+
+data "influxdb_databases" "test" {
+	name_regex = "^${influxdb_database.test.name}$"
+	depends_on = ["influxdb_database.other"]
+}
+`
+
+// testDatabasesDataSourceConfig depends on the database through name_regex
+// alone, as data sources with depends_on are read again on every plan. The
+// _internal database of the fake server is filtered out instead of another
+// one.
+var testDatabasesDataSourceConfig = `
+resource "influxdb_database" "test" {
+	name = "terraform-ds-test"
+	retention_policies {
+		name = "1week"
+		duration = "1w"
+		shardgroupduration = "1h"
+		default = "true"
+	}
+}
+
+data "influxdb_databases" "test" {
+	name_regex = "^${influxdb_database.test.name}$"
+}
+`
@@ -0,0 +1,74 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceUser() *schema.Resource {
+	return &schema.Resource{
+		Read: readUserDataSource,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"admin": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"grant": dataSourceGrantSchema(),
+		},
+	}
+}
+
+// dataSourceGrantSchema describes the privileges of a user as reported by
+// SHOW GRANTS, sorted by database.
+func dataSourceGrantSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"database": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"privilege": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func readUserDataSource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+	name := d.Get("name").(string)
+
+	users, err := listUsers(conn)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.name == name {
+			privileges, err := listGrants(conn, name)
+			if err != nil {
+				return err
+			}
+
+			d.SetId(fmt.Sprintf("influxdb-user:%s", name))
+			d.Set("admin", user.admin)
+			if err := d.Set("grant", flattenGrants(privileges)); err != nil {
+				return err
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user %q not found", name)
+}
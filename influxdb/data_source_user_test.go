@@ -0,0 +1,86 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInfluxDBUserDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserDataSourceConfig,
+				Check:  testCheckUserDataSource(),
+			},
+		},
+	})
+}
+
+func TestInfluxDBUserDataSource(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccUserDataSourceConfig,
+				Check:  testCheckUserDataSource(),
+			},
+		},
+	})
+}
+
+func testCheckUserDataSource() resource.TestCheckFunc {
+	return resource.ComposeTestCheckFunc(
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "admin", "false",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "grant.#", "2",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "grant.0.database", "terraform-ds-blue",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "grant.0.privilege", "ALL",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "grant.1.database", "terraform-ds-green",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_user.test", "grant.1.privilege", "READ",
+		),
+	)
+}
+
+var testAccUserDataSourceConfig = `
+resource "influxdb_database" "green" {
+    name = "terraform-ds-green"
+}
+
+resource "influxdb_database" "blue" {
+    name = "terraform-ds-blue"
+}
+
+resource "influxdb_user" "test" {
+    name = "terraform_ds_test"
+    password = "terraform"
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+
+    grant {
+      database = "${influxdb_database.blue.name}"
+      privilege = "ALL"
+    }
+}
+
+data "influxdb_user" "test" {
+    name = "${influxdb_user.test.name}"
+}
+`
@@ -0,0 +1,96 @@
+package influxdb
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceUsers() *schema.Resource {
+	return &schema.Resource{
+		Read: readUsersDataSource,
+
+		Schema: map[string]*schema.Schema{
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+			},
+			"names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"admins": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"users": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"admin": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"grant": dataSourceGrantSchema(),
+					},
+				},
+			},
+		},
+	}
+}
+
+func readUsersDataSource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	var nameRegex *regexp.Regexp
+	if v, ok := d.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	users, err := listUsers(conn)
+	if err != nil {
+		return err
+	}
+
+	var names = []string{}
+	var admins = []string{}
+	var result = []map[string]interface{}{}
+	for _, user := range users {
+		if nameRegex != nil && !nameRegex.MatchString(user.name) {
+			continue
+		}
+
+		privileges, err := listGrants(conn, user.name)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, user.name)
+		if user.admin {
+			admins = append(admins, user.name)
+		}
+		result = append(result, map[string]interface{}{
+			"name":  user.name,
+			"admin": user.admin,
+			"grant": flattenGrants(privileges),
+		})
+	}
+
+	d.SetId(hashSum(strings.Join(names, ",")))
+	d.Set("names", names)
+	d.Set("admins", admins)
+	if err := d.Set("users", result); err != nil {
+		return err
+	}
+
+	return nil
+}
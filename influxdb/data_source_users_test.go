@@ -0,0 +1,106 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccInfluxDBUsersDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUsersDataSourceConfig,
+				Check:  testCheckUsersDataSource(),
+			},
+		},
+	})
+}
+
+func TestInfluxDBUsersDataSource(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testUsersDataSourceConfig,
+				Check:  testCheckUsersDataSource(),
+			},
+		},
+	})
+}
+
+func testCheckUsersDataSource() resource.TestCheckFunc {
+	return resource.ComposeTestCheckFunc(
+		resource.TestCheckResourceAttr(
+			"data.influxdb_users.test", "names.#", "2",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_users.test", "admins.#", "1",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_users.test", "admins.0", "terraform_ds_admin",
+		),
+		resource.TestCheckResourceAttr(
+			"data.influxdb_users.test", "users.#", "2",
+		),
+	)
+}
+
+var testAccUsersDataSourceConfig = `
+resource "influxdb_database" "green" {
+    name = "terraform-ds-green"
+}
+
+resource "influxdb_user" "admin" {
+    name = "terraform_ds_admin"
+    password = "terraform"
+    admin = true
+}
+
+resource "influxdb_user" "reader" {
+    name = "terraform_ds_reader"
+    password = "terraform"
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+}
+
+data "influxdb_users" "test" {
+    name_regex = "^terraform_ds_"
+    depends_on = ["influxdb_user.admin", "influxdb_user.reader"]
+}
+`
+
+// testUsersDataSourceConfig depends on the users through name_regex alone,
+// as data sources with depends_on are read again on every plan.
+var testUsersDataSourceConfig = `
+resource "influxdb_database" "green" {
+    name = "terraform-ds-green"
+}
+
+resource "influxdb_user" "admin" {
+    name = "terraform_ds_admin"
+    password = "terraform"
+    admin = true
+}
+
+resource "influxdb_user" "reader" {
+    name = "terraform_ds_reader"
+    password = "terraform"
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+}
+
+data "influxdb_users" "test" {
+    name_regex = "^(${influxdb_user.admin.name}|${influxdb_user.reader.name})$"
+}
+`
@@ -0,0 +1,515 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// fluxAggregates maps the InfluxQL functions continuous queries commonly use
+// to the Flux functions aggregateWindow accepts.
+var fluxAggregates = map[string]string{
+	"count":  "count",
+	"first":  "first",
+	"last":   "last",
+	"max":    "max",
+	"mean":   "mean",
+	"median": "median",
+	"min":    "min",
+	"mode":   "mode",
+	"spread": "spread",
+	"stddev": "stddev",
+	"sum":    "sum",
+}
+
+// fluxTask is the Flux equivalent of a continuous query.
+type fluxTask struct {
+	Script string
+	Every  string
+	Offset string
+}
+
+type cqSelection struct {
+	function string
+	field    string
+	alias    string
+}
+
+type cqMeasurement struct {
+	database string
+	policy   string
+	name     string
+	regex    bool
+	// backreference is set for the :MEASUREMENT target, which writes to
+	// the measurement the data was read from.
+	backreference bool
+}
+
+// continuousQuery is the subset of a continuous query's SELECT statement
+// that can be translated to Flux.
+type continuousQuery struct {
+	selections []cqSelection
+	into       cqMeasurement
+	from       cqMeasurement
+	where      string
+	interval   time.Duration
+	offset     time.Duration
+	tags       []string
+	allTags    bool
+}
+
+// continuousQueryToFlux translates a continuous query of the form
+//
+//	SELECT <aggregate>(<field>) [, ...] INTO <target> FROM <source>
+//	[WHERE <tag conditions>] GROUP BY time(<interval>)[, * | <tags>]
+//
+// into a Flux task reading from and writing to the buckets named
+// "<database>/<retention policy>", as InfluxDB 2.x names the buckets it
+// upgrades from 1.x.
+func continuousQueryToFlux(database, policy, query, every, forDuration string) (*fluxTask, error) {
+	cq, err := parseContinuousQuerySelect(query)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported continuous query %q: %s", query, err)
+	}
+
+	for _, m := range []*cqMeasurement{&cq.from, &cq.into} {
+		if m.database == "" {
+			m.database = database
+		}
+		if m.policy == "" {
+			m.policy = policy
+		}
+	}
+
+	schedule := cq.interval
+	if every != "" {
+		if schedule, err = parseDuration(every); err != nil {
+			return nil, err
+		}
+	}
+	window := cq.interval
+	if forDuration != "" {
+		if window, err = parseDuration(forDuration); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		pipelines []string
+		fields    = map[string]int{}
+	)
+	for _, s := range cq.selections {
+		var lines []string
+		lines = append(lines,
+			fmt.Sprintf("from(bucket: %s)", fluxString(cq.from.database+"/"+cq.from.policy)),
+			fmt.Sprintf("range(start: -%s)", fluxDuration(window)))
+
+		if cq.from.regex {
+			lines = append(lines, fmt.Sprintf("filter(fn: (r) => r._measurement =~ %s)", cq.from.name))
+		} else {
+			lines = append(lines, fmt.Sprintf("filter(fn: (r) => r._measurement == %s)", fluxString(cq.from.name)))
+		}
+		if s.field != "*" {
+			lines = append(lines, fmt.Sprintf("filter(fn: (r) => r._field == %s)", fluxString(s.field)))
+		}
+		if cq.where != "" {
+			lines = append(lines, fmt.Sprintf("filter(fn: (r) => %s)", cq.where))
+		}
+
+		if !cq.allTags {
+			columns := []string{`"_measurement"`, `"_field"`}
+			for _, tag := range cq.tags {
+				columns = append(columns, fluxString(tag))
+			}
+			lines = append(lines, fmt.Sprintf("group(columns: [%s])", strings.Join(columns, ", ")))
+		}
+
+		windowing := fmt.Sprintf("every: %s", fluxDuration(cq.interval))
+		if cq.offset != 0 {
+			windowing += fmt.Sprintf(", offset: %s", fluxDuration(cq.offset))
+		}
+		// Continuous queries write their points at the start of each
+		// window, and never for empty ones.
+		lines = append(lines, fmt.Sprintf("aggregateWindow(%s, fn: %s, timeSrc: \"_start\", createEmpty: false)", windowing, fluxAggregates[s.function]))
+
+		// InfluxQL names the fields it writes after the alias or the
+		// function, numbering the duplicates.
+		if s.field == "*" {
+			lines = append(lines, fmt.Sprintf("map(fn: (r) => ({r with _field: %s + r._field}))", fluxString(s.function+"_")))
+		} else {
+			name := s.alias
+			if name == "" {
+				name = s.function
+			}
+			if n := fields[name]; n > 0 {
+				fields[name]++
+				name = fmt.Sprintf("%s_%d", name, n)
+			} else {
+				fields[name] = 1
+			}
+			lines = append(lines, fmt.Sprintf("set(key: \"_field\", value: %s)", fluxString(name)))
+		}
+
+		if !cq.into.backreference {
+			lines = append(lines, fmt.Sprintf("set(key: \"_measurement\", value: %s)", fluxString(cq.into.name)))
+		}
+		lines = append(lines, fmt.Sprintf("to(bucket: %s)", fluxString(cq.into.database+"/"+cq.into.policy)))
+
+		pipelines = append(pipelines, strings.Join(lines, "\n  |> ")+"\n")
+	}
+
+	task := &fluxTask{
+		Script: strings.Join(pipelines, "\n"),
+		Every:  fluxDuration(schedule),
+	}
+	if cq.offset != 0 {
+		task.Offset = fluxDuration(cq.offset)
+	}
+	return task, nil
+}
+
+// fluxDuration formats a duration as a Flux duration literal.
+func fluxDuration(d time.Duration) string {
+	s := formatDuration(d)
+	if strings.HasSuffix(s, "u") {
+		s += "s"
+	}
+	return s
+}
+
+// cqParser is a recursive descent parser over the tokens of a SELECT
+// statement, as split by tokenizeQuery.
+type cqParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func parseContinuousQuerySelect(query string) (*continuousQuery, error) {
+	p := &cqParser{tokens: tokenizeQuery(query)}
+	cq := &continuousQuery{}
+
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	for {
+		s, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		cq.selections = append(cq.selections, s)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	into, err := p.parseMeasurement(true)
+	if err != nil {
+		return nil, err
+	}
+	cq.into = *into
+
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseMeasurement(false)
+	if err != nil {
+		return nil, err
+	}
+	cq.from = *from
+	if p.peek().text == "," {
+		return nil, fmt.Errorf("reading from several measurements is not supported")
+	}
+
+	if p.accept("WHERE") {
+		if cq.where, err = p.parseCondition(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expect("GROUP"); err != nil {
+		return nil, err
+	}
+	if err := p.expect("BY"); err != nil {
+		return nil, err
+	}
+	if err := p.parseDimensions(cq); err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(p.peek().text, "fill") {
+		p.next()
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		switch option := p.next(); strings.ToLower(option.text) {
+		case "none", "null":
+		default:
+			return nil, fmt.Errorf("fill(%s) is not supported, only fill(none) and fill(null) are", option.text)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if token := p.peek(); token.text != "" {
+		return nil, fmt.Errorf("%s is not supported", token.text)
+	}
+
+	return cq, nil
+}
+
+func (p *cqParser) peek() queryToken {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *cqParser) next() queryToken {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// accept consumes the next token if it is text.
+func (p *cqParser) accept(text string) bool {
+	if p.peek().text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *cqParser) expect(text string) error {
+	if token := p.next(); token.text != text {
+		if token.text == "" {
+			return fmt.Errorf("expected %s, found the end of the query", text)
+		}
+		return fmt.Errorf("expected %s, found %s", text, token.text)
+	}
+	return nil
+}
+
+// parseIdentifier parses a bare or double quoted identifier.
+func (p *cqParser) parseIdentifier() (string, error) {
+	token := p.next()
+	switch {
+	case token.kind == wordToken && token.text != "" && !influxqlKeywords[token.text]:
+		return token.text, nil
+	case token.kind == quotedToken && strings.HasPrefix(token.text, `"`):
+		return strings.Replace(strings.Trim(token.text, `"`), `\"`, `"`, -1), nil
+	case token.text == "":
+		return "", fmt.Errorf("expected an identifier, found the end of the query")
+	}
+	return "", fmt.Errorf("expected an identifier, found %s", token.text)
+}
+
+// parseSelection parses an <aggregate>(<field>) [AS <alias>] selection.
+func (p *cqParser) parseSelection() (cqSelection, error) {
+	var s cqSelection
+
+	function := p.next()
+	if function.kind != wordToken || p.peek().text != "(" {
+		return s, fmt.Errorf("%s is not supported, only <aggregate>(<field>) selections are", function.text)
+	}
+	s.function = strings.ToLower(function.text)
+	if _, ok := fluxAggregates[s.function]; !ok {
+		return s, fmt.Errorf("the %s function is not supported", function.text)
+	}
+	p.next()
+
+	if p.accept("*") {
+		s.field = "*"
+	} else {
+		field, err := p.parseIdentifier()
+		if err != nil {
+			return s, err
+		}
+		s.field = field
+	}
+	if token := p.next(); token.text != ")" {
+		return s, fmt.Errorf("%s(...) must take a single field, found %s", function.text, token.text)
+	}
+
+	if p.accept("AS") {
+		if s.field == "*" {
+			return s, fmt.Errorf("aliasing %s(*) is not supported", function.text)
+		}
+		alias, err := p.parseIdentifier()
+		if err != nil {
+			return s, err
+		}
+		s.alias = alias
+	}
+
+	return s, nil
+}
+
+// parseMeasurement parses a measurement, optionally qualified with its
+// database and retention policy, or a regular expression when reading.
+func (p *cqParser) parseMeasurement(into bool) (*cqMeasurement, error) {
+	m := &cqMeasurement{}
+
+	if token := p.peek(); token.kind == quotedToken && strings.HasPrefix(token.text, "/") {
+		if into {
+			return nil, fmt.Errorf("writing into %s is not supported", token.text)
+		}
+		p.next()
+		m.name, m.regex = token.text, true
+		return m, nil
+	}
+
+	var parts []string
+	for {
+		if into && p.peek().text == ":" {
+			p.next()
+			if token := p.next(); !strings.EqualFold(token.text, "MEASUREMENT") {
+				return nil, fmt.Errorf("expected :MEASUREMENT, found :%s", token.text)
+			}
+			m.backreference = true
+			parts = append(parts, "")
+			break
+		}
+
+		part := ""
+		if p.peek().text != "." {
+			var err error
+			if part, err = p.parseIdentifier(); err != nil {
+				return nil, err
+			}
+		}
+		parts = append(parts, part)
+		if !p.accept(".") {
+			break
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		m.name = parts[0]
+	case 2:
+		m.policy, m.name = parts[0], parts[1]
+	case 3:
+		m.database, m.policy, m.name = parts[0], parts[1], parts[2]
+	default:
+		return nil, fmt.Errorf("invalid measurement %s", strings.Join(parts, "."))
+	}
+	if m.name == "" && !m.backreference {
+		return nil, fmt.Errorf("missing measurement name")
+	}
+
+	return m, nil
+}
+
+// parseCondition parses a WHERE clause made of tag comparisons and returns
+// it as a Flux predicate on r.
+func (p *cqParser) parseCondition() (string, error) {
+	var b strings.Builder
+	for {
+		if p.accept("(") {
+			inner, err := p.parseCondition()
+			if err != nil {
+				return "", err
+			}
+			if err := p.expect(")"); err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "(%s)", inner)
+		} else {
+			comparison, err := p.parseComparison()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(comparison)
+		}
+
+		switch {
+		case p.accept("AND"):
+			b.WriteString(" and ")
+		case p.accept("OR"):
+			b.WriteString(" or ")
+		default:
+			return b.String(), nil
+		}
+	}
+}
+
+func (p *cqParser) parseComparison() (string, error) {
+	tag, err := p.parseIdentifier()
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(tag, "time") {
+		return "", fmt.Errorf("conditions on time are not supported")
+	}
+
+	op := p.next()
+	value := p.next()
+	switch op.text {
+	case "=", "!=", "<>":
+		if value.kind != quotedToken || !strings.HasPrefix(value.text, "'") {
+			return "", fmt.Errorf("only tags compared to string literals are supported, found %s %s %s", tag, op.text, value.text)
+		}
+		literal := strings.Replace(strings.Trim(value.text, "'"), `\'`, `'`, -1)
+		if op.text == "=" {
+			return fmt.Sprintf("r[%s] == %s", fluxString(tag), fluxString(literal)), nil
+		}
+		return fmt.Sprintf("r[%s] != %s", fluxString(tag), fluxString(literal)), nil
+	case "=~", "!~":
+		if value.kind != quotedToken || !strings.HasPrefix(value.text, "/") {
+			return "", fmt.Errorf("%s must be followed by a regular expression, found %s", op.text, value.text)
+		}
+		return fmt.Sprintf("r[%s] %s %s", fluxString(tag), op.text, value.text), nil
+	}
+	return "", fmt.Errorf("the %s operator is not supported", op.text)
+}
+
+// parseDimensions parses the GROUP BY clause, which must include time().
+func (p *cqParser) parseDimensions(cq *continuousQuery) error {
+	for {
+		switch token := p.peek(); {
+		case token.text == "*":
+			p.next()
+			cq.allTags = true
+		case strings.EqualFold(token.text, "time") && token.kind == wordToken:
+			p.next()
+			if cq.interval != 0 {
+				return fmt.Errorf("time() is used several times")
+			}
+			if err := p.expect("("); err != nil {
+				return err
+			}
+			interval, err := parseDuration(p.next().text)
+			if err != nil || interval == 0 {
+				return fmt.Errorf("time() must be given a duration interval")
+			}
+			cq.interval = interval
+			if p.accept(",") {
+				if cq.offset, err = parseDuration(p.next().text); err != nil {
+					return fmt.Errorf("time() must be given a duration offset")
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return err
+			}
+		default:
+			tag, err := p.parseIdentifier()
+			if err != nil {
+				return err
+			}
+			cq.tags = append(cq.tags, tag)
+		}
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if cq.interval == 0 {
+		return fmt.Errorf("GROUP BY must include time()")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContinuousQueryToFlux(t *testing.T) {
+	cases := []struct {
+		query       string
+		every       string
+		forDuration string
+		expected    fluxTask
+	}{
+		{
+			query: `SELECT mean(value) INTO cpu_1h FROM cpu GROUP BY time(1h), *`,
+			expected: fluxTask{
+				Every: "1h",
+				Script: `from(bucket: "telegraf/autogen")
+  |> range(start: -1h)
+  |> filter(fn: (r) => r._measurement == "cpu")
+  |> filter(fn: (r) => r._field == "value")
+  |> aggregateWindow(every: 1h, fn: mean, timeSrc: "_start", createEmpty: false)
+  |> set(key: "_field", value: "mean")
+  |> set(key: "_measurement", value: "cpu_1h")
+  |> to(bucket: "telegraf/autogen")
+`,
+			},
+		},
+		{
+			query:       `select MAX("usage idle") as peak, max(usage_user) into "telegraf"."one_year"."cpu" from one_week.cpu where host =~ /^web/ and (region = 'eu' OR region != 'it\'s') group by time(60m, 15m), host fill(none)`,
+			every:       "30m",
+			forDuration: "2h",
+			expected: fluxTask{
+				Every:  "30m",
+				Offset: "15m",
+				Script: `from(bucket: "telegraf/one_week")
+  |> range(start: -2h)
+  |> filter(fn: (r) => r._measurement == "cpu")
+  |> filter(fn: (r) => r._field == "usage idle")
+  |> filter(fn: (r) => r["host"] =~ /^web/ and (r["region"] == "eu" or r["region"] != "it's"))
+  |> group(columns: ["_measurement", "_field", "host"])
+  |> aggregateWindow(every: 1h, offset: 15m, fn: max, timeSrc: "_start", createEmpty: false)
+  |> set(key: "_field", value: "peak")
+  |> set(key: "_measurement", value: "cpu")
+  |> to(bucket: "telegraf/one_year")
+
+from(bucket: "telegraf/one_week")
+  |> range(start: -2h)
+  |> filter(fn: (r) => r._measurement == "cpu")
+  |> filter(fn: (r) => r._field == "usage_user")
+  |> filter(fn: (r) => r["host"] =~ /^web/ and (r["region"] == "eu" or r["region"] != "it's"))
+  |> group(columns: ["_measurement", "_field", "host"])
+  |> aggregateWindow(every: 1h, offset: 15m, fn: max, timeSrc: "_start", createEmpty: false)
+  |> set(key: "_field", value: "max")
+  |> set(key: "_measurement", value: "cpu")
+  |> to(bucket: "telegraf/one_year")
+`,
+			},
+		},
+		{
+			query: `SELECT sum(*), sum(*) INTO "archive"..:MEASUREMENT FROM /.*/ GROUP BY time(1d)`,
+			expected: fluxTask{
+				Every: "1d",
+				Script: `from(bucket: "telegraf/autogen")
+  |> range(start: -1d)
+  |> filter(fn: (r) => r._measurement =~ /.*/)
+  |> group(columns: ["_measurement", "_field"])
+  |> aggregateWindow(every: 1d, fn: sum, timeSrc: "_start", createEmpty: false)
+  |> map(fn: (r) => ({r with _field: "sum_" + r._field}))
+  |> to(bucket: "archive/autogen")
+
+from(bucket: "telegraf/autogen")
+  |> range(start: -1d)
+  |> filter(fn: (r) => r._measurement =~ /.*/)
+  |> group(columns: ["_measurement", "_field"])
+  |> aggregateWindow(every: 1d, fn: sum, timeSrc: "_start", createEmpty: false)
+  |> map(fn: (r) => ({r with _field: "sum_" + r._field}))
+  |> to(bucket: "archive/autogen")
+`,
+			},
+		},
+		{
+			query: `SELECT count(a), count(b), count(c) INTO cnt FROM m GROUP BY time(1m)`,
+			expected: fluxTask{
+				Every: "1m",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		task, err := continuousQueryToFlux("telegraf", "autogen", tc.query, tc.every, tc.forDuration)
+		if err != nil {
+			t.Errorf("%s: %s", tc.query, err)
+			continue
+		}
+		if task.Every != tc.expected.Every || task.Offset != tc.expected.Offset {
+			t.Errorf("%s: expected every %q and offset %q, got %q and %q",
+				tc.query, tc.expected.Every, tc.expected.Offset, task.Every, task.Offset)
+		}
+		if tc.expected.Script != "" && task.Script != tc.expected.Script {
+			t.Errorf("%s: expected script\n%s\ngot\n%s", tc.query, tc.expected.Script, task.Script)
+		}
+	}
+}
+
+func TestContinuousQueryToFlux_fieldNames(t *testing.T) {
+	task, err := continuousQueryToFlux("telegraf", "autogen",
+		`SELECT count(a), count(b), count(c) INTO cnt FROM m GROUP BY time(1m)`, "", "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, name := range []string{`"count"`, `"count_1"`, `"count_2"`} {
+		if !strings.Contains(task.Script, `set(key: "_field", value: `+name+`)`) {
+			t.Errorf("expected a field named %s in\n%s", name, task.Script)
+		}
+	}
+}
+
+func TestContinuousQueryToFlux_unsupported(t *testing.T) {
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{`SELECT value INTO b FROM a GROUP BY time(1h)`, "only <aggregate>(<field>) selections"},
+		{`SELECT percentile(value, 95) INTO b FROM a GROUP BY time(1h)`, "the percentile function is not supported"},
+		{`SELECT mean(value) * 2 INTO b FROM a GROUP BY time(1h)`, "expected INTO, found *"},
+		{`SELECT mean(value) FROM a GROUP BY time(1h)`, "expected INTO, found FROM"},
+		{`SELECT mean(value) INTO b FROM a, c GROUP BY time(1h)`, "several measurements"},
+		{`SELECT mean(value) INTO b FROM a WHERE value > 2 GROUP BY time(1h)`, "the > operator is not supported"},
+		{`SELECT mean(value) INTO b FROM a WHERE time > now() - 1h GROUP BY time(1h)`, "conditions on time"},
+		{`SELECT mean(value) INTO b FROM a WHERE host = 2 GROUP BY time(1h)`, "string literals"},
+		{`SELECT mean(value) INTO b FROM a GROUP BY host`, "GROUP BY must include time()"},
+		{`SELECT mean(value) INTO b FROM a GROUP BY time(1h) fill(0)`, "fill(0) is not supported"},
+		{`SELECT mean(value) INTO b FROM a GROUP BY time(1h) LIMIT 10`, "LIMIT is not supported"},
+		{`SELECT mean(*) AS m INTO b FROM a GROUP BY time(1h)`, "aliasing mean(*)"},
+		{`SELECT mean(value) INTO b FROM (SELECT value FROM a) GROUP BY time(1h)`, "expected an identifier, found ("},
+		{`SELECT mean(value) INTO b FROM a GROUP BY time(1h), time(2h)`, "time() is used several times"},
+	}
+
+	for _, tc := range cases {
+		_, err := continuousQueryToFlux("telegraf", "autogen", tc.query, "", "")
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.query)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) || !strings.HasPrefix(err.Error(), "unsupported continuous query") {
+			t.Errorf("%s: expected an error about %q, got %q", tc.query, tc.err, err)
+		}
+	}
+}
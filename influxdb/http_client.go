@@ -0,0 +1,142 @@
+package influxdb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultUserAgent is the User-Agent client.Client sends, kept for servers
+// and proxies that match on it.
+const defaultUserAgent = "InfluxDBClient"
+
+// httpClientConfig holds the provider settings shared by the HTTP clients of
+// the 1.x and 2.x APIs.
+type httpClientConfig struct {
+	unsafeSsl         bool
+	caCertificate     string
+	clientCertificate string
+	clientKey         string
+	tlsServerName     string
+	timeout           time.Duration
+	userAgent         string
+	headers           map[string]string
+	// unixSocket is the path of the socket to dial instead of the host of
+	// the request URL.
+	unixSocket string
+}
+
+func newHTTPClient(config httpClientConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.unsafeSsl,
+		ServerName:         config.tlsServerName,
+	}
+
+	if config.caCertificate != "" {
+		ca, err := readPEM(config.caCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ca_certificate: %s", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("ca_certificate does not contain any PEM encoded certificate")
+		}
+	}
+
+	if config.clientCertificate != "" || config.clientKey != "" {
+		if config.clientCertificate == "" || config.clientKey == "" {
+			return nil, fmt.Errorf("client_certificate and client_key must be set together")
+		}
+		cert, err := readPEM(config.clientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client_certificate: %s", err)
+		}
+		key, err := readPEM(config.clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading client_key: %s", err)
+		}
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	userAgent := config.userAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	if config.unixSocket != "" {
+		var dialer net.Dialer
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", config.unixSocket)
+		}
+	}
+
+	return &http.Client{
+		Timeout: config.timeout,
+		Transport: &headerTransport{
+			base:      transport,
+			userAgent: userAgent,
+			headers:   config.headers,
+		},
+	}, nil
+}
+
+// parseUnixSocketURL returns the path of the socket named by a
+// unix:///path/to/influxdb.sock URL, and the URL requests are sent to over
+// it. InfluxDB serves the same HTTP API on its socket, so the host of that
+// URL is only a placeholder.
+func parseUnixSocketURL(u *url.URL) (string, *url.URL, error) {
+	if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return "", nil, fmt.Errorf("invalid InfluxDB URL %q: the socket path must be absolute, e.g. unix:///var/run/influxdb.sock", u)
+	}
+	return u.Path, &url.URL{Scheme: "http", Host: "localhost"}, nil
+}
+
+// readPEM returns s if it holds PEM content, and otherwise reads the file it
+// names.
+func readPEM(s string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "-----BEGIN") {
+		return []byte(s), nil
+	}
+	return ioutil.ReadFile(s)
+}
+
+// headerTransport sets the User-Agent and the custom headers configured on
+// the provider on every request.
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given.
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+len(t.headers)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	r.Header.Set("User-Agent", t.userAgent)
+	for k, v := range t.headers {
+		r.Header.Set(k, v)
+	}
+
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,445 @@
+package influxdb
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/influxdata/influxdb/client"
+)
+
+// testPKI is a certificate authority with a server certificate for
+// influxdb.internal and a client certificate, all PEM encoded.
+type testPKI struct {
+	caCert     string
+	serverCert tls.Certificate
+	clientCert string
+	clientKey  string
+	pool       *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Terraform Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, template *x509.Certificate) (certPEM, keyPEM string) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+		return certPEM, keyPEM
+	}
+
+	pki := &testPKI{
+		caCert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		pool:   x509.NewCertPool(),
+	}
+	pki.pool.AddCert(ca)
+
+	serverCert, serverKey := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "influxdb.internal"},
+		DNSNames:    []string{"influxdb.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if pki.serverCert, err = tls.X509KeyPair([]byte(serverCert), []byte(serverKey)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	pki.clientCert, pki.clientKey = issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "terraform"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	return pki
+}
+
+// newMutualTLSServer starts a server answering /ping and /query that
+// requires a client certificate issued by the test CA.
+func newMutualTLSServer(pki *testPKI, handler http.HandlerFunc) *httptest.Server {
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pki.serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pki.pool,
+	}
+	srv.StartTLS()
+	return srv
+}
+
+func testConfigure(t *testing.T, raw map[string]interface{}) (*providerMeta, error) {
+	d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, raw)
+	meta, err := configure(d)
+	if err != nil {
+		return nil, err
+	}
+	return meta.(*providerMeta), nil
+}
+
+func TestConfigure_mutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	var requests []*http.Request
+	srv := newMutualTLSServer(pki, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0,"series":[{"name":"databases","columns":["name"],"values":[["_internal"]]}]}]}`))
+	})
+	defer srv.Close()
+
+	// Certificates can be given as files as well as PEM content.
+	dir, err := ioutil.TempDir("", "terraform-provider-influxdb")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile := dir + "/client.key"
+	if err := ioutil.WriteFile(keyFile, []byte(pki.clientKey), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	meta, err := testConfigure(t, map[string]interface{}{
+		"url":                srv.URL,
+		"ca_certificate":     pki.caCert,
+		"client_certificate": pki.clientCert,
+		"client_key":         keyFile,
+		"tls_server_name":    "influxdb.internal",
+		"user_agent":         "terraform-test",
+		"headers": map[string]interface{}{
+			"X-Tenant": "metrics",
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := meta.conn.Query(client.Query{Command: "SHOW DATABASES"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.Error() != nil || resp.Results[0].Series[0].Values[0][0] != "_internal" {
+		t.Fatalf("unexpected response: %#v", resp)
+	}
+
+	for _, r := range requests {
+		if ua := r.Header.Get("User-Agent"); ua != "terraform-test" {
+			t.Errorf("expected the user agent to be terraform-test, got %q", ua)
+		}
+		if tenant := r.Header.Get("X-Tenant"); tenant != "metrics" {
+			t.Errorf("expected the X-Tenant header to be set, got %q", tenant)
+		}
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "terraform" {
+			t.Errorf("expected the client certificate to be presented")
+		}
+	}
+}
+
+func TestConfigure_TLSErrors(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := newMutualTLSServer(pki, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer srv.Close()
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		err  string
+	}{
+		{
+			name: "unknown CA",
+			raw: map[string]interface{}{
+				"url":                srv.URL,
+				"client_certificate": pki.clientCert,
+				"client_key":         pki.clientKey,
+				"tls_server_name":    "influxdb.internal",
+			},
+			err: "certificate",
+		},
+		{
+			name: "server name mismatch",
+			raw: map[string]interface{}{
+				"url":                srv.URL,
+				"ca_certificate":     pki.caCert,
+				"client_certificate": pki.clientCert,
+				"client_key":         pki.clientKey,
+			},
+			err: "127.0.0.1",
+		},
+		{
+			name: "missing client certificate",
+			raw: map[string]interface{}{
+				"url":             srv.URL,
+				"ca_certificate":  pki.caCert,
+				"tls_server_name": "influxdb.internal",
+			},
+			err: "unable to connect to InfluxDB",
+		},
+		{
+			name: "client key without certificate",
+			raw: map[string]interface{}{
+				"url":        srv.URL,
+				"client_key": pki.clientKey,
+			},
+			err: "must be set together",
+		},
+		{
+			name: "invalid CA",
+			raw: map[string]interface{}{
+				"url":            srv.URL,
+				"ca_certificate": "-----BEGIN CERTIFICATE-----\nnot a certificate\n-----END CERTIFICATE-----",
+			},
+			err: "does not contain any PEM encoded certificate",
+		},
+		{
+			name: "missing CA file",
+			raw: map[string]interface{}{
+				"url":            srv.URL,
+				"ca_certificate": "/nonexistent/ca.pem",
+			},
+			err: "error reading ca_certificate",
+		},
+	}
+
+	for _, tc := range cases {
+		tc.raw["ping_on_configure"] = true
+		tc.raw["max_retries"] = 0
+		_, err := testConfigure(t, tc.raw)
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected an error about %q, got %q", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestConfigure_timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	_, err := testConfigure(t, map[string]interface{}{
+		"url":               srv.URL,
+		"timeout":           "100ms",
+		"ping_on_configure": true,
+		"max_retries":       0,
+	})
+	if err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
+
+func TestConfigure_unixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terraform-provider-influxdb")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := dir + "/influxdb.sock"
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var commands []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		commands = append(commands, r.FormValue("q"))
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	srv.Listener.Close()
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	meta, err := testConfigure(t, map[string]interface{}{
+		"url":               "unix://" + socket,
+		"ping_on_configure": true,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := exec(meta.conn, "CREATE DATABASE \"terraform-test\""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(commands) != 1 || commands[0] != "CREATE DATABASE \"terraform-test\"" {
+		t.Fatalf("unexpected commands: %q", commands)
+	}
+
+	for _, u := range []string{"unix://influxdb.sock", "unix:influxdb.sock"} {
+		if _, err := testConfigure(t, map[string]interface{}{"url": u}); err == nil || !strings.Contains(err.Error(), "must be absolute") {
+			t.Errorf("%s: expected an error about the socket path, got %v", u, err)
+		}
+	}
+
+	// Sockets can't be combined with several nodes.
+	for _, raw := range []map[string]interface{}{
+		{"url": "unix://" + socket, "urls": []interface{}{"http://influxdb-1:8086", "http://influxdb-2:8086"}},
+		{"urls": []interface{}{"http://influxdb-1:8086", "unix://" + socket}},
+	} {
+		if _, err := testConfigure(t, raw); err == nil || !strings.Contains(err.Error(), "unix socket") {
+			t.Errorf("%v: expected an error about the unix socket, got %v", raw, err)
+		}
+	}
+}
+
+func TestValidateGoDuration(t *testing.T) {
+	for _, v := range []string{"", "30s", "1m30s"} {
+		if _, errs := validateGoDuration(v, "timeout"); len(errs) != 0 {
+			t.Errorf("expected %q to be valid, got %v", v, errs)
+		}
+	}
+	for _, v := range []string{"30", "-1s", "soon"} {
+		if _, errs := validateGoDuration(v, "timeout"); len(errs) == 0 {
+			t.Errorf("expected %q to be invalid", v)
+		}
+	}
+}
+
+func TestConfigure_sharedSecret(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	defer srv.Close()
+
+	meta, err := testConfigure(t, map[string]interface{}{
+		"url":           srv.URL,
+		"username":      "terraform",
+		"password":      "not-sent",
+		"shared_secret": "s3cr3t",
+		"jwt_ttl":       "30s",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	start := time.Now()
+	if err := exec(meta.conn, "CREATE DATABASE \"terraform-test\""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected a ping and a query, got %d requests", len(requests))
+	}
+	for _, r := range requests {
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Errorf("%s: expected the password not to be sent", r.URL.Path)
+		}
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			t.Fatalf("%s: expected a bearer token, got %q", r.URL.Path, auth)
+		}
+		parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+		if len(parts) != 3 {
+			t.Fatalf("%s: malformed token %q", r.URL.Path, auth)
+		}
+
+		mac := hmac.New(sha256.New, []byte("s3cr3t"))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if signature, _ := base64.RawURLEncoding.DecodeString(parts[2]); !hmac.Equal(signature, mac.Sum(nil)) {
+			t.Errorf("%s: invalid signature", r.URL.Path)
+		}
+
+		var header map[string]string
+		decodeJWTPart(t, parts[0], &header)
+		if header["alg"] != "HS256" || header["typ"] != "JWT" {
+			t.Errorf("%s: unexpected header %v", r.URL.Path, header)
+		}
+
+		var claims map[string]interface{}
+		decodeJWTPart(t, parts[1], &claims)
+		if claims["username"] != "terraform" {
+			t.Errorf("%s: expected the username claim to be terraform, got %v", r.URL.Path, claims["username"])
+		}
+		exp, ok := claims["exp"].(float64)
+		if !ok {
+			t.Fatalf("%s: expected a numeric exp claim, got %v", r.URL.Path, claims["exp"])
+		}
+		if expires := time.Unix(int64(exp), 0); expires.Before(start.Add(29*time.Second)) || expires.After(time.Now().Add(30*time.Second)) {
+			t.Errorf("%s: expected the token to expire in 30s, expires at %s", r.URL.Path, expires)
+		}
+	}
+
+	for _, tc := range []struct {
+		raw map[string]interface{}
+		err string
+	}{
+		{map[string]interface{}{"shared_secret": "s3cr3t"}, "username must be set"},
+		{map[string]interface{}{"shared_secret": "s3cr3t", "username": "terraform", "jwt_ttl": "0s"}, "jwt_ttl must be a positive duration"},
+	} {
+		tc.raw["url"] = srv.URL
+		if _, err := testConfigure(t, tc.raw); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected an error about %q, got %v", tc.err, err)
+		}
+	}
+}
+
+func decodeJWTPart(t *testing.T, part string, v interface{}) {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
@@ -0,0 +1,52 @@
+package influxdb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// InfluxQL reads the same escape sequences in quoted identifiers and string
+// literals: a backslash followed by the quote, another backslash or n. Any
+// other character, including a raw newline, ends or breaks the statement.
+var (
+	identifierReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	stringReplacer     = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
+)
+
+// quoteIdentifier quotes the name of a database, retention policy, user or
+// continuous query so that it is read back as-is, whatever it contains.
+func quoteIdentifier(ident string) string {
+	return `"` + identifierReplacer.Replace(ident) + `"`
+}
+
+// quoteString quotes a string literal, such as a password.
+func quoteString(s string) string {
+	return `'` + stringReplacer.Replace(s) + `'`
+}
+
+// execWithPassword runs the statement built by statement from the token
+// standing for a password. Servers that accept bound parameters for any token
+// get the password as a $password parameter, keeping it out of the statement;
+// others get it as a quoted string literal.
+func execWithPassword(conn *queryClient, statement func(password string) string, password string) error {
+	if conn.supportsParams() {
+		return execParams(conn, statement("$password"), map[string]interface{}{"password": password})
+	}
+	return exec(conn, statement(quoteString(password)))
+}
+
+var serverVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// supportsParams reports whether a 1.x server substitutes bound parameters
+// for any token of a statement, rather than only for values in expressions.
+// This is the case of every release from 1.8 on.
+func supportsParams(version string) bool {
+	m := serverVersionRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return major == 1 && minor >= 8
+}
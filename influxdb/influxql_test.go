@@ -0,0 +1,318 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// adversarialNames are names and passwords that break statements built
+// without escaping them.
+var adversarialNames = []string{
+	"plain",
+	"with space",
+	`double"quote`,
+	`single'quote`,
+	`back\slash`,
+	`trailing\`,
+	`\"`,
+	`\'`,
+	`\\"; DROP DATABASE "telegraf`,
+	`'; DROP USER admin; --`,
+	`"; GRANT ALL PRIVILEGES TO "eve`,
+	"new\nline",
+	"carriage\rreturn\ttab",
+	"$password",
+	"50%off",
+	"%s%d",
+	"%%s",
+	"ünïcødé ✓",
+	"SELECT",
+	"",
+}
+
+// unquoteInfluxQL reads a quoted identifier or string literal the way the
+// InfluxQL scanner does.
+func unquoteInfluxQL(s string) (string, error) {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("%q is not quoted", s)
+	}
+	quote := rune(s[0])
+
+	var b strings.Builder
+	runes := []rune(s[1 : len(s)-1])
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\n':
+			return "", fmt.Errorf("%q: bad string", s)
+		case quote:
+			return "", fmt.Errorf("%q: unescaped quote", s)
+		case '\\':
+			i++
+			if i == len(runes) {
+				return "", fmt.Errorf("%q: unterminated escape", s)
+			}
+			switch runes[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case '\\', '"', '\'':
+				b.WriteRune(runes[i])
+			default:
+				return "", fmt.Errorf("%q: bad escape \\%c", s, runes[i])
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+func TestQuote(t *testing.T) {
+	for _, quote := range []func(string) string{quoteIdentifier, quoteString} {
+		for _, name := range adversarialNames {
+			quoted := quote(name)
+			actual, err := unquoteInfluxQL(quoted)
+			if err != nil {
+				t.Errorf("%q: %s", name, err)
+				continue
+			}
+			if actual != name {
+				t.Errorf("expected %s to read back as %q, got %q", quoted, name, actual)
+			}
+			if tokens := tokenizeQuery(quoted); len(tokens) != 1 {
+				t.Errorf("expected %s to be a single token, got %v", quoted, tokens)
+			}
+		}
+	}
+}
+
+func TestSupportsParams(t *testing.T) {
+	cases := map[string]bool{
+		"1.8.10":       true,
+		"v1.11.0":      true,
+		"1.7.11":       false,
+		"1.8.0-c1.8.0": true,
+		"v2.7.1":       false,
+		"unknown":      false,
+		"":             false,
+	}
+	for version, expected := range cases {
+		if actual := supportsParams(version); actual != expected {
+			t.Errorf("%q: expected %t, got %t", version, expected, actual)
+		}
+	}
+}
+
+// statementRecorder is a server that records the statements and bound
+// parameters it receives, reporting version in its pings.
+type statementRecorder struct {
+	*httptest.Server
+	statements []string
+	params     []map[string]interface{}
+}
+
+func newStatementRecorder(version string) *statementRecorder {
+	s := &statementRecorder{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Influxdb-Version", version)
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.RawQuery != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"expected the statement in the body"}`))
+			return
+		}
+
+		var params map[string]interface{}
+		if p := r.FormValue("params"); p != "" {
+			if err := json.Unmarshal([]byte(p), &params); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		s.statements = append(s.statements, r.FormValue("q"))
+		s.params = append(s.params, params)
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	return s
+}
+
+func (s *statementRecorder) client() *queryClient {
+	u, _ := url.Parse(s.URL)
+	return newQueryClient([]url.URL{*u}, queryAuth{}, http.DefaultClient, retryPolicy{waitMin: time.Millisecond, waitMax: time.Millisecond}, false)
+}
+
+// checkStatement checks that statement is made of the expected tokens, the
+// quoted ones reading back as the given values.
+func checkStatement(t *testing.T, statement string, expected ...string) {
+	tokens := tokenizeQuery(statement)
+	if len(tokens) != len(expected) {
+		t.Errorf("expected %s to have %d tokens, got %d: %v", statement, len(expected), len(tokens), tokens)
+		return
+	}
+	for i, token := range tokens {
+		text := token.text
+		if token.kind == quotedToken {
+			var err error
+			if text, err = unquoteInfluxQL(text); err != nil {
+				t.Errorf("%s: %s", statement, err)
+				continue
+			}
+		}
+		if text != expected[i] {
+			t.Errorf("%s: expected token %d to be %q, got %q", statement, i, expected[i], text)
+		}
+	}
+}
+
+func TestStatementBuilders(t *testing.T) {
+	srv := newStatementRecorder("1.7.11")
+	defer srv.Close()
+	conn := srv.client()
+
+	for _, name := range adversarialNames {
+		srv.statements = nil
+		builders := []struct {
+			run      func() error
+			expected []string
+		}{
+			{
+				func() error { return createUserWithPassword(conn, name, name, false) },
+				[]string{"CREATE", "USER", name, "WITH", "PASSWORD", name},
+			},
+			{
+				func() error { return setUserPassword(conn, name, name) },
+				[]string{"SET", "PASSWORD", "FOR", name, "=", name},
+			},
+			{
+				func() error { return grantPrivilegeOn(conn, "READ", name, name) },
+				[]string{"GRANT", "READ", "ON", name, "TO", name},
+			},
+			{
+				func() error { return revokePrivilegeOn(conn, "WRITE", name, name) },
+				[]string{"REVOKE", "WRITE", "ON", name, "FROM", name},
+			},
+			{
+				func() error { return grantAllOn(conn, name) },
+				[]string{"GRANT", "ALL", "PRIVILEGES", "TO", name},
+			},
+			{
+				func() error { return createRetentionPolicy(conn, name, "1d", 1, "", true, name) },
+				[]string{"CREATE", "RETENTION", "POLICY", name, "ON", name, "DURATION", "1d", "REPLICATION", "1", "DEFAULT"},
+			},
+			{
+				func() error { return deleteRetentionPolicy(conn, name, name) },
+				[]string{"DROP", "RETENTION", "POLICY", name, "ON", name},
+			},
+			{
+				func() error {
+					statement, err := continuousQueryStatement(name, name, "EVERY 1h", "SELECT 1")
+					if err != nil {
+						return err
+					}
+					return exec(conn, statement)
+				},
+				[]string{"CREATE", "CONTINUOUS", "QUERY", name, "ON", name, "RESAMPLE", "EVERY", "1h", "BEGIN", "SELECT", "1", "END"},
+			},
+		}
+
+		for _, b := range builders {
+			if err := b.run(); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			checkStatement(t, srv.statements[len(srv.statements)-1], b.expected...)
+		}
+	}
+
+	// Durations can't be quoted, so the builders refuse anything but a
+	// duration rather than sending it.
+	srv.statements = nil
+	for _, duration := range []string{"1d REPLICATION 3", "1h; DROP DATABASE telegraf", "1 week", `1d"`, "1h\nDROP USER admin"} {
+		builders := []func() error{
+			func() error { return createRetentionPolicy(conn, "rp", duration, 1, "", false, "telegraf") },
+			func() error { return createRetentionPolicy(conn, "rp", "1d", 1, duration, false, "telegraf") },
+			func() error { return updateRetentionPolicy(conn, "rp", duration, 1, "", false, "telegraf") },
+			func() error { return updateRetentionPolicy(conn, "rp", "1d", 1, duration, true, "telegraf") },
+			func() error {
+				_, err := continuousQueryStatement("cq", "telegraf", "EVERY "+duration, "SELECT 1")
+				return err
+			},
+			func() error {
+				_, err := continuousQueryStatement("cq", "telegraf", "EVERY 1h FOR "+duration, "SELECT 1")
+				return err
+			},
+		}
+		for i, b := range builders {
+			if err := b(); err == nil {
+				t.Errorf("%q: expected builder %d to fail", duration, i)
+			}
+		}
+	}
+	if len(srv.statements) != 0 {
+		t.Errorf("expected no statements to be sent, got %q", srv.statements)
+	}
+}
+
+func TestExecWithPassword(t *testing.T) {
+	password := `'; DROP USER admin; --`
+
+	// Servers from 1.8 on get the password as a bound parameter.
+	srv := newStatementRecorder("1.8.10")
+	defer srv.Close()
+	if err := setUserPassword(srv.client(), "paul", password); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if srv.statements[0] != `SET PASSWORD FOR "paul" = $password` {
+		t.Fatalf("expected the password to be a bound parameter, got %s", srv.statements[0])
+	}
+	if srv.params[0]["password"] != password {
+		t.Fatalf("expected the password parameter to be %q, got %v", password, srv.params[0])
+	}
+
+	// Older servers get it as a string literal.
+	old := newStatementRecorder("1.7.11")
+	defer old.Close()
+	if err := setUserPassword(old.client(), "paul", password); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if old.params[0] != nil {
+		t.Fatalf("expected no bound parameter, got %v", old.params[0])
+	}
+	checkStatement(t, old.statements[0], "SET", "PASSWORD", "FOR", "paul", "=", password)
+
+	// Neither the name nor the password can change the statement, on
+	// either kind of server.
+	for _, name := range adversarialNames {
+		srv.statements, srv.params, old.statements, old.params = nil, nil, nil, nil
+		for _, conn := range []*queryClient{srv.client(), old.client()} {
+			if err := createUserWithPassword(conn, name, name, true); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if err := setUserPassword(conn, name, name); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		}
+
+		expected := []string{
+			"CREATE USER " + quoteIdentifier(name) + " WITH PASSWORD $password WITH ALL PRIVILEGES",
+			"SET PASSWORD FOR " + quoteIdentifier(name) + " = $password",
+		}
+		for i, statement := range srv.statements {
+			if statement != expected[i] {
+				t.Errorf("expected %s, got %s", expected[i], statement)
+			}
+			if srv.params[i]["password"] != name {
+				t.Errorf("expected the password parameter to be %q, got %v", name, srv.params[i])
+			}
+		}
+		checkStatement(t, old.statements[0], "CREATE", "USER", name, "WITH", "PASSWORD", name, "WITH", "ALL", "PRIVILEGES")
+		checkStatement(t, old.statements[1], "SET", "PASSWORD", "FOR", name, "=", name)
+	}
+}
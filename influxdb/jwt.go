@@ -0,0 +1,34 @@
+package influxdb
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// jwtHeader is the header of the tokens signed with the shared secret of
+// InfluxDB 1.x, which only accepts HMAC signatures.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtClaims are the claims InfluxDB 1.x reads from a bearer token: the user
+// the request is made as, and when the token expires, in Unix seconds.
+type jwtClaims struct {
+	Username string `json:"username"`
+	Exp      int64  `json:"exp"`
+}
+
+// signJWT returns a token for username, valid until exp, signed with
+// secret.
+func signJWT(secret, username string, exp time.Time) (string, error) {
+	claims, err := json.Marshal(jwtClaims{Username: username, Exp: exp.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	payload := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -3,22 +3,38 @@ package influxdb
 import (
 	"fmt"
 	"net/url"
-	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/influxdata/influxdb/client"
 )
 
-var quoteReplacer = strings.NewReplacer(`"`, `\"`)
-
 // Provider returns a terraform.ResourceProvider.
 func Provider() terraform.ResourceProvider {
 	return &schema.Provider{
+		DataSourcesMap: map[string]*schema.Resource{
+			"influxdb_databases": dataSourceDatabases(),
+			"influxdb_users":     dataSourceUsers(),
+			"influxdb_user":      dataSourceUser(),
+
+			"influxdb_continuous_query_flux": dataSourceContinuousQueryFlux(),
+		},
+
 		ResourcesMap: map[string]*schema.Resource{
 			"influxdb_database":         resourceDatabase(),
 			"influxdb_user":             resourceUser(),
 			"influxdb_continuous_query": resourceContinuousQuery(),
+			"influxdb_retention_policy": resourceRetentionPolicy(),
+			"influxdb_grant":            resourceGrant(),
+			"influxdb_organization":     resourceOrganization(),
+			"influxdb_bucket":           resourceBucket(),
+			"influxdb_authorization":    resourceAuthorization(),
+			"influxdb_dbrp_mapping":     resourceDBRPMapping(),
+			"influxdb_v2_user":          resourceV2User(),
+			"influxdb_org_member":       resourceOrgMembership("members"),
+			"influxdb_org_owner":        resourceOrgMembership("owners"),
+			"influxdb_task":             resourceTask(),
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -29,6 +45,16 @@ func Provider() terraform.ResourceProvider {
 					"INFLUXDB_URL", "http://localhost:8086/",
 				),
 			},
+			"urls": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"replicate_ddl": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			"username": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -41,56 +67,225 @@ func Provider() terraform.ResourceProvider {
 				StateFunc:   hashSum,
 				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_PASSWORD", ""),
 			},
+			"shared_secret": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_SHARED_SECRET", ""),
+			},
+			"jwt_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_JWT_TTL", "1m"),
+				ValidateFunc: validateGoDuration,
+			},
 			"skip_ssl_verify": {
 				Type:        schema.TypeBool,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_SKIP_SSL_VERIFY", "0"),
 			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_TOKEN", ""),
+			},
+			"org": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_ORG", ""),
+			},
+			"ca_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_CA_CERTIFICATE", ""),
+			},
+			"client_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_CLIENT_CERTIFICATE", ""),
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_CLIENT_KEY", ""),
+			},
+			"tls_server_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_TLS_SERVER_NAME", ""),
+			},
+			"timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_TIMEOUT", ""),
+				ValidateFunc: validateGoDuration,
+			},
+			"user_agent": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_USER_AGENT", defaultUserAgent),
+			},
+			"max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_MAX_RETRIES", 3),
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					if v.(int) < 0 {
+						errors = append(errors, fmt.Errorf("%q must not be negative", k))
+					}
+					return
+				},
+			},
+			"retry_wait_min": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_RETRY_WAIT_MIN", "1s"),
+				ValidateFunc: validateGoDuration,
+			},
+			"retry_wait_max": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("INFLUXDB_RETRY_WAIT_MAX", "30s"),
+				ValidateFunc: validateGoDuration,
+			},
+			"ping_on_configure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("INFLUXDB_PING_ON_CONFIGURE", false),
+			},
+			"headers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 		},
 
 		ConfigureFunc: configure,
 	}
 }
 
+// providerMeta holds the clients shared by all resources. conn speaks
+// InfluxQL to the /query endpoint of InfluxDB 1.x, while api talks to the
+// /api/v2 endpoints of InfluxDB 2.x.
+type providerMeta struct {
+	conn *queryClient
+	api  *apiClient
+}
+
 func configure(d *schema.ResourceData) (interface{}, error) {
-	url, err := url.Parse(d.Get("url").(string))
+	u, err := url.Parse(d.Get("url").(string))
 	if err != nil {
 		return nil, fmt.Errorf("invalid InfluxDB URL: %s", err)
 	}
 
-	config := client.Config{
-		URL:       *url,
-		Username:  d.Get("username").(string),
-		Password:  d.Get("password").(string),
-		UnsafeSsl: d.Get("skip_ssl_verify").(bool),
+	var unixSocket string
+	if u.Scheme == "unix" {
+		if unixSocket, u, err = parseUnixSocketURL(u); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := []url.URL{*u}
+	if urls := d.Get("urls").([]interface{}); len(urls) > 0 {
+		// Every request would be sent to the one socket, whatever node
+		// it is meant for.
+		if unixSocket != "" {
+			return nil, fmt.Errorf("urls can't be set along with the unix socket url %q", d.Get("url").(string))
+		}
+		if nodes, err = parseNodeURLs(urls); err != nil {
+			return nil, err
+		}
 	}
 
-	conn, err := client.NewClient(config)
+	// The durations have already been validated.
+	timeout, _ := time.ParseDuration(d.Get("timeout").(string))
+	retryWaitMin, _ := time.ParseDuration(d.Get("retry_wait_min").(string))
+	retryWaitMax, _ := time.ParseDuration(d.Get("retry_wait_max").(string))
+	if retryWaitMax < retryWaitMin {
+		return nil, fmt.Errorf("retry_wait_max must not be shorter than retry_wait_min")
+	}
+
+	headers := make(map[string]string)
+	for k, v := range d.Get("headers").(map[string]interface{}) {
+		headers[k] = v.(string)
+	}
+
+	httpClient, err := newHTTPClient(httpClientConfig{
+		unsafeSsl:         d.Get("skip_ssl_verify").(bool),
+		caCertificate:     d.Get("ca_certificate").(string),
+		clientCertificate: d.Get("client_certificate").(string),
+		clientKey:         d.Get("client_key").(string),
+		tlsServerName:     d.Get("tls_server_name").(string),
+		timeout:           timeout,
+		userAgent:         d.Get("user_agent").(string),
+		headers:           headers,
+		unixSocket:        unixSocket,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	_, _, err = conn.Ping()
-	if err != nil {
-		return nil, fmt.Errorf("error pinging server: %s", err)
+	auth := queryAuth{
+		username:     d.Get("username").(string),
+		password:     d.Get("password").(string),
+		sharedSecret: d.Get("shared_secret").(string),
+	}
+	if auth.sharedSecret != "" {
+		if auth.username == "" {
+			return nil, fmt.Errorf("username must be set to authenticate with shared_secret")
+		}
+		auth.jwtTTL, _ = time.ParseDuration(d.Get("jwt_ttl").(string))
+		if auth.jwtTTL <= 0 {
+			return nil, fmt.Errorf("jwt_ttl must be a positive duration")
+		}
+	}
+
+	conn := newQueryClient(nodes, auth, httpClient, retryPolicy{
+		maxRetries: d.Get("max_retries").(int),
+		waitMin:    retryWaitMin,
+		waitMax:    retryWaitMax,
+	}, d.Get("replicate_ddl").(bool))
+
+	if d.Get("ping_on_configure").(bool) {
+		if err := conn.connect(); err != nil {
+			return nil, err
+		}
 	}
 
-	return conn, nil
+	api := newAPIClient(nodes[0], d.Get("token").(string), d.Get("org").(string), httpClient)
+
+	return &providerMeta{conn: conn, api: api}, nil
+}
+
+// validateGoDuration validates the durations of the HTTP client settings,
+// which use Go's format rather than InfluxQL's.
+func validateGoDuration(v interface{}, k string) (ws []string, errors []error) {
+	if v.(string) == "" {
+		return
+	}
+	if d, err := time.ParseDuration(v.(string)); err != nil || d < 0 {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as 30s or 1m", k))
+	}
+	return
 }
 
-func quoteIdentifier(ident string) string {
-	return fmt.Sprintf(`%q`, quoteReplacer.Replace(ident))
+func exec(conn *queryClient, query string) error {
+	return execParams(conn, query, nil)
 }
 
-func exec(conn *client.Client, query string) error {
-	resp, err := conn.Query(client.Query{
+// execParams runs query with the values of its bound parameters.
+func execParams(conn *queryClient, query string, params map[string]interface{}) error {
+	resp, err := conn.QueryParams(client.Query{
 		Command: query,
-	})
+	}, params)
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
-	return nil
+	// Errors of a statement are reported in its result, and authorization
+	// errors for the whole query.
+	return resp.Error()
 }
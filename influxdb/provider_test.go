@@ -1,6 +1,7 @@
 package influxdb
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -14,6 +15,9 @@ import (
 //
 // To run the tests against a remote InfluxDB server, set the INFLUXDB_URL,
 // INFLUXDB_USERNAME and INFLUXDB_PASSWORD environment variables.
+//
+// The tests of the InfluxDB 2.x resources run against an in-process stand-in
+// for the /api/v2 endpoints (see fakeAPI) and need no server.
 
 var testAccProviders map[string]terraform.ResourceProvider
 var testAccProvider *schema.Provider
@@ -34,3 +38,26 @@ func TestProvider(t *testing.T) {
 func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
+
+func TestConfigure_lazyConnection(t *testing.T) {
+	// Nothing listens on port 1, yet the provider can be configured, as the
+	// server may be created by the same configuration.
+	d := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		"url":         "http://127.0.0.1:1/",
+		"max_retries": 0,
+	})
+	meta, err := configure(d)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = exec(meta.(*providerMeta).conn, "CREATE DATABASE \"terraform-test\"")
+	if err == nil || !strings.HasPrefix(err.Error(), "unable to connect to InfluxDB at 127.0.0.1:1: ") {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+
+	d.Set("ping_on_configure", true)
+	if _, err := configure(d); err == nil || !strings.HasPrefix(err.Error(), "unable to connect to InfluxDB") {
+		t.Fatalf("expected configure to fail with ping_on_configure, got %v", err)
+	}
+}
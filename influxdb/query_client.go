@@ -0,0 +1,318 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// queryClient runs InfluxQL statements against the /query endpoint of
+// InfluxDB 1.x. It speaks the same protocol as client.Client, whose HTTP
+// transport can't be configured beyond skipping TLS verification.
+//
+// Statements are sent to one node at a time, moving on to the next node when
+// the active one can't be reached. With replicateDDL, statements changing the
+// server are sent to every node instead, and SHOW statements are compared
+// across nodes.
+type queryClient struct {
+	nodes        []url.URL
+	auth         queryAuth
+	httpClient   *http.Client
+	retry        retryPolicy
+	replicateDDL bool
+
+	// connected is set once a node has answered a ping. The provider
+	// doesn't contact the server until it is used, as it may be created by
+	// the same Terraform configuration. active is the index of the node
+	// statements are sent to, and version the version it reported.
+	mu        sync.Mutex
+	connected bool
+	active    int
+	version   string
+}
+
+// queryAuth holds the credentials of the requests. When sharedSecret is set,
+// each request carries a token signed with it that expires after jwtTTL,
+// instead of the password.
+type queryAuth struct {
+	username     string
+	password     string
+	sharedSecret string
+	jwtTTL       time.Duration
+}
+
+func newQueryClient(nodes []url.URL, auth queryAuth, httpClient *http.Client, retry retryPolicy, replicateDDL bool) *queryClient {
+	return &queryClient{
+		nodes:        nodes,
+		auth:         auth,
+		httpClient:   httpClient,
+		retry:        retry,
+		replicateDDL: replicateDDL,
+	}
+}
+
+// newRequest builds a request to node. The form is sent in the body, as the
+// URL of a request ends up in error messages and in the logs of proxies.
+func (c *queryClient) newRequest(node url.URL, method, path string, form url.Values) (*http.Request, error) {
+	u := node
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	switch {
+	case c.auth.sharedSecret != "":
+		// Tokens are signed per request so that they stay short-lived, even
+		// when retries or long applies outlast the TTL.
+		token, err := signJWT(c.auth.sharedSecret, c.auth.username, time.Now().Add(c.auth.jwtTTL))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.auth.username != "":
+		req.SetBasicAuth(c.auth.username, c.auth.password)
+	}
+	return req, nil
+}
+
+// Query sends q to the server. Like client.Client, it only returns an error
+// when the response can't be read; errors reported by the server are left in
+// the response.
+//
+// Commands made only of statements that can safely be repeated are retried
+// according to the retry policy when the server can't be reached or is
+// unavailable. The error of the last attempt is returned.
+func (c *queryClient) Query(q client.Query) (*client.Response, error) {
+	return c.QueryParams(q, nil)
+}
+
+// QueryParams sends q to the server with the values of its bound
+// parameters, such as $password.
+func (c *queryClient) QueryParams(q client.Query, params map[string]interface{}) (*client.Response, error) {
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	if c.replicateDDL && len(c.nodes) > 1 {
+		if isShowCommand(q.Command) {
+			return c.compareNodes(q, params)
+		}
+		if !isReadOnlyCommand(q.Command) {
+			return c.replicate(q, params)
+		}
+	}
+
+	var resp *client.Response
+	err := c.retry.run(c.retries(q), func() error {
+		// A node that can't be dialed never received the statement, so
+		// it can be sent to the next one.
+		return c.onActiveNode(isDialError, func(node url.URL) (err error) {
+			resp, err = c.query(node, q, params)
+			return err
+		})
+	})
+	return resp, err
+}
+
+// supportsParams reports whether the active node accepts bound parameters
+// in place of identifiers and string literals.
+func (c *queryClient) supportsParams() bool {
+	if err := c.connect(); err != nil {
+		// The error is reported by the statement itself.
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return supportsParams(c.version)
+}
+
+func (c *queryClient) retries(q client.Query) int {
+	if isRetryableCommand(q.Command) {
+		return c.retry.maxRetries
+	}
+	return 0
+}
+
+// connect checks that a node can be reached the first time it is called,
+// retrying while the nodes are unavailable.
+func (c *queryClient) connect() error {
+	c.mu.Lock()
+	connected := c.connected
+	c.mu.Unlock()
+	if connected {
+		return nil
+	}
+
+	err := c.retry.run(c.retry.maxRetries, func() error {
+		return c.onActiveNode(func(error) bool { return true }, func(node url.URL) error {
+			_, version, err := c.ping(node)
+			if err == nil {
+				c.mu.Lock()
+				c.version = version
+				c.mu.Unlock()
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("unable to connect to InfluxDB at %s: %s", c.hosts(), err)
+	}
+
+	c.mu.Lock()
+	c.connected = true
+	c.mu.Unlock()
+	return nil
+}
+
+// onActiveNode calls f with the active node, and then with the following
+// nodes as long as it fails with an error failover accepts. The node f last
+// ran on becomes the active node.
+func (c *queryClient) onActiveNode(failover func(error) bool, f func(node url.URL) error) error {
+	c.mu.Lock()
+	active := c.active
+	c.mu.Unlock()
+
+	var errs nodeErrors
+	for i := range c.nodes {
+		n := (active + i) % len(c.nodes)
+		err := f(c.nodes[n])
+		if err != nil && failover(err) {
+			errs = append(errs, nodeError{host: c.nodes[n].Host, err: err})
+			continue
+		}
+
+		if n != active {
+			log.Printf("[WARN] InfluxDB at %s can't be reached, using %s instead", c.nodes[active].Host, c.nodes[n].Host)
+			c.mu.Lock()
+			c.active = n
+			c.mu.Unlock()
+		}
+		return err
+	}
+	return errs.err()
+}
+
+func (c *queryClient) hosts() string {
+	hosts := make([]string, len(c.nodes))
+	for i, node := range c.nodes {
+		hosts[i] = node.Host
+	}
+	return strings.Join(hosts, ", ")
+}
+
+func (c *queryClient) query(node url.URL, q client.Query, params map[string]interface{}) (*client.Response, error) {
+	form := url.Values{"q": {q.Command}, "db": {q.Database}}
+	if len(params) > 0 {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		form.Set("params", string(b))
+	}
+
+	req, err := c.newRequest(node, "POST", "/query", form)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response client.Response
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&response); err != nil {
+		// Ignore EOF errors if we got an invalid status code.
+		if !(err == io.EOF && resp.StatusCode != http.StatusOK) {
+			return nil, err
+		}
+	}
+
+	if isTransientStatus(resp.StatusCode) {
+		return &response, &transientStatusError{statusCode: resp.StatusCode, err: response.Error()}
+	}
+	if resp.StatusCode != http.StatusOK && response.Error() == nil {
+		return &response, fmt.Errorf("received status code %d from server", resp.StatusCode)
+	}
+	return &response, nil
+}
+
+// ping checks that a node is up, returning how long the request took and
+// the version of the server.
+func (c *queryClient) ping(node url.URL) (time.Duration, string, error) {
+	now := time.Now()
+
+	req, err := c.newRequest(node, "GET", "/ping", nil)
+	if err != nil {
+		return 0, "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	return time.Since(now), resp.Header.Get("X-Influxdb-Version"), nil
+}
+
+// nodeError is an error returned by a node.
+type nodeError struct {
+	host string
+	err  error
+}
+
+// nodeErrors are the errors of a request that failed on several nodes.
+type nodeErrors []nodeError
+
+func (e nodeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = fmt.Sprintf("%s: %s", err.host, err.err)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// err returns nil if there are no errors, the error itself if there is a
+// single one, so that the messages of a single server are left unchanged,
+// and e otherwise.
+func (e nodeErrors) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0].err
+	}
+	return e
+}
+
+// isDialError reports whether err is a failure to connect to the server,
+// before any statement was sent.
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
@@ -0,0 +1,692 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testQueryUser     = "terraform"
+	testQueryPassword = "terraform-password"
+)
+
+// fakeInfluxQL is an in-process stand-in for the /query endpoint of an
+// InfluxDB 1.x server, keeping the databases, retention policies, continuous
+// queries and users it is told to create in memory and reporting them the
+// way InfluxDB 1.8 does.
+type fakeInfluxQL struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	databases []*fakeDatabase
+	users     map[string]*fakeInfluxUser
+
+	// denied holds the patterns of the statements the provider isn't
+	// authorized to run, and failures the error of the statements the
+	// server fails to run, keyed by pattern. Patterns are matched against
+	// the statements with their tokens separated by single spaces.
+	denied   []*regexp.Regexp
+	failures map[*regexp.Regexp]string
+}
+
+// fakeDatabase keeps its retention policies and continuous queries in the
+// order they were created, which is the order InfluxDB lists them in.
+type fakeDatabase struct {
+	name          string
+	defaultPolicy string
+	policies      []*fakeRetentionPolicy
+	queries       []fakeContinuousQuery
+}
+
+type fakeRetentionPolicy struct {
+	name               string
+	duration           time.Duration
+	shardGroupDuration time.Duration
+	replicaN           int
+}
+
+type fakeContinuousQuery struct {
+	name  string
+	query string
+}
+
+// fakeInfluxUser holds the privileges of the user keyed by database. Like
+// InfluxDB, revoking a privilege leaves "NO PRIVILEGES" behind.
+type fakeInfluxUser struct {
+	password   string
+	admin      bool
+	privileges map[string]string
+}
+
+// fakePrivileges maps privileges to the bits InfluxDB stores them as.
+var fakePrivileges = map[string]int{"NO PRIVILEGES": 0, "READ": 1, "WRITE": 2, "ALL": 3}
+
+// fakeStatement is a statement received by the fake server, with its quoted
+// identifiers and strings read back and its bound parameters substituted.
+type fakeStatement struct {
+	text  string
+	words []string
+}
+
+// newFakeInfluxQL starts a server with the _internal database of the
+// monitoring service, the given databases and an admin user for the provider.
+func newFakeInfluxQL(databases ...string) *fakeInfluxQL {
+	f := &fakeInfluxQL{
+		databases: []*fakeDatabase{{
+			name:          "_internal",
+			defaultPolicy: "monitor",
+			policies:      []*fakeRetentionPolicy{{name: "monitor", duration: week, shardGroupDuration: day, replicaN: 1}},
+		}},
+		users: map[string]*fakeInfluxUser{
+			testQueryUser: {password: testQueryPassword, admin: true, privileges: map[string]string{}},
+		},
+		failures: make(map[*regexp.Regexp]string),
+	}
+	for _, name := range databases {
+		f.databases = append(f.databases, newFakeDatabase(name))
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// newFakeDatabase returns a database with the autogen retention policy
+// InfluxDB creates along with it.
+func newFakeDatabase(name string) *fakeDatabase {
+	return &fakeDatabase{
+		name:          name,
+		defaultPolicy: "autogen",
+		policies:      []*fakeRetentionPolicy{{name: "autogen", shardGroupDuration: week, replicaN: 1}},
+	}
+}
+
+// providerConfig returns a provider block pointing at the fake server.
+func (f *fakeInfluxQL) providerConfig() string {
+	return fmt.Sprintf(`
+provider "influxdb" {
+  url         = "%s"
+  username    = "%s"
+  password    = "%s"
+  max_retries = 0
+}
+`, f.URL, testQueryUser, testQueryPassword)
+}
+
+// deny makes the server refuse statements matching pattern, as it does for
+// users without the required privileges.
+func (f *fakeInfluxQL) deny(pattern string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.denied = append(f.denied, regexp.MustCompile(pattern))
+}
+
+// fail makes the server report message as the error of statements matching
+// pattern.
+func (f *fakeInfluxQL) fail(pattern, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[regexp.MustCompile(pattern)] = message
+}
+
+// allow clears the patterns given to deny and fail.
+func (f *fakeInfluxQL) allow() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.denied = nil
+	f.failures = make(map[*regexp.Regexp]string)
+}
+
+// run executes statements as an administrator would outside of Terraform,
+// to change what the provider finds on its next refresh.
+func (f *fakeInfluxQL) run(t *testing.T, query string) {
+	statements, err := parseFakeStatements(query, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range statements {
+		if _, err := f.execute(s); err != nil {
+			t.Fatalf("%s: %s", s.text, err)
+		}
+	}
+}
+
+func (f *fakeInfluxQL) user(name string) *fakeInfluxUser {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.users[name]
+}
+
+func (f *fakeInfluxQL) database(name string) *fakeDatabase {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.findDatabase(name)
+}
+
+func (f *fakeInfluxQL) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("X-Influxdb-Version", "1.8.10")
+	if r.URL.Path == "/ping" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.URL.Path != "/query" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	username, password, _ := r.BasicAuth()
+	if u, ok := f.users[username]; !ok || u.password != password {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authorization failed"})
+		return
+	}
+
+	var params map[string]interface{}
+	if p := r.FormValue("params"); p != "" {
+		if err := json.Unmarshal([]byte(p), &params); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "error parsing query parameters: " + err.Error()})
+			return
+		}
+	}
+	statements, err := parseFakeStatements(r.FormValue("q"), params)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "error parsing query: " + err.Error()})
+		return
+	}
+
+	// Like InfluxDB, check every statement before running any of them.
+	for _, s := range statements {
+		for _, pattern := range f.denied {
+			if pattern.MatchString(s.text) {
+				writeJSON(w, http.StatusForbidden, map[string]string{
+					"error": fmt.Sprintf("error authorizing query: %s not authorized to execute statement '%s', requires admin privilege", username, s.text),
+				})
+				return
+			}
+		}
+	}
+
+	var results []map[string]interface{}
+	failed := false
+	for i, s := range statements {
+		result := map[string]interface{}{"statement_id": i}
+		if failed {
+			result["error"] = "not executed"
+		} else if err := f.failure(s); err != "" {
+			result["error"] = err
+		} else if series, err := f.execute(s); err != nil {
+			result["error"] = err.Error()
+		} else if series != nil {
+			result["series"] = series
+		}
+		failed = failed || result["error"] != nil
+		results = append(results, result)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func (f *fakeInfluxQL) failure(s fakeStatement) string {
+	for pattern, message := range f.failures {
+		if pattern.MatchString(s.text) {
+			return message
+		}
+	}
+	return ""
+}
+
+// execute runs a statement, returning the series of its result.
+func (f *fakeInfluxQL) execute(s fakeStatement) ([]map[string]interface{}, error) {
+	w := s.words
+	switch {
+	case s.match("CREATE", "DATABASE", "*"):
+		if f.findDatabase(w[2]) == nil {
+			f.databases = append(f.databases, newFakeDatabase(w[2]))
+		}
+
+	case s.match("DROP", "DATABASE", "*"):
+		for i, db := range f.databases {
+			if db.name == w[2] {
+				f.databases = append(f.databases[:i], f.databases[i+1:]...)
+				break
+			}
+		}
+		// The privileges on the database go along with it.
+		for _, u := range f.users {
+			delete(u.privileges, w[2])
+		}
+
+	case s.hasPrefix("CREATE", "RETENTION", "POLICY", "*", "ON", "*"), s.hasPrefix("ALTER", "RETENTION", "POLICY", "*", "ON", "*"):
+		db, err := f.lookupDatabase(w[5])
+		if err != nil {
+			return nil, err
+		}
+		return nil, db.setPolicy(w[3], strings.EqualFold(w[0], "CREATE"), w[6:])
+
+	case s.match("DROP", "RETENTION", "POLICY", "*", "ON", "*"):
+		db, err := f.lookupDatabase(w[5])
+		if err != nil {
+			return nil, err
+		}
+		for i, rp := range db.policies {
+			if rp.name == w[3] {
+				db.policies = append(db.policies[:i], db.policies[i+1:]...)
+				break
+			}
+		}
+		if db.defaultPolicy == w[3] {
+			db.defaultPolicy = ""
+		}
+
+	case s.hasPrefix("CREATE", "CONTINUOUS", "QUERY", "*", "ON", "*"):
+		db, err := f.lookupDatabase(w[5])
+		if err != nil {
+			return nil, err
+		}
+		query, err := db.formatContinuousQuery(w[3], s.text)
+		if err != nil {
+			return nil, err
+		}
+		for _, cq := range db.queries {
+			if cq.name == w[3] {
+				if cq.query != query {
+					return nil, fmt.Errorf("continuous query already exists")
+				}
+				return nil, nil
+			}
+		}
+		db.queries = append(db.queries, fakeContinuousQuery{name: w[3], query: query})
+
+	case s.match("DROP", "CONTINUOUS", "QUERY", "*", "ON", "*"):
+		db, err := f.lookupDatabase(w[5])
+		if err != nil {
+			return nil, err
+		}
+		for i, cq := range db.queries {
+			if cq.name == w[3] {
+				db.queries = append(db.queries[:i], db.queries[i+1:]...)
+				return nil, nil
+			}
+		}
+		return nil, fmt.Errorf("continuous query not found")
+
+	case s.match("SHOW", "DATABASES"):
+		var values [][]interface{}
+		for _, db := range f.databases {
+			values = append(values, []interface{}{db.name})
+		}
+		return fakeSeries("databases", []string{"name"}, values), nil
+
+	case s.match("SHOW", "RETENTION", "POLICIES", "ON", "*"):
+		db, err := f.lookupDatabase(w[4])
+		if err != nil {
+			return nil, err
+		}
+		var values [][]interface{}
+		for _, rp := range db.policies {
+			values = append(values, []interface{}{
+				rp.name, rp.duration.String(), rp.shardGroupDuration.String(), rp.replicaN, rp.name == db.defaultPolicy,
+			})
+		}
+		return fakeSeries("", []string{"name", "duration", "shardGroupDuration", "replicaN", "default"}, values), nil
+
+	case s.match("SHOW", "CONTINUOUS", "QUERIES"):
+		// Every database gets a series, even those without continuous
+		// queries.
+		var series []map[string]interface{}
+		for _, db := range f.databases {
+			var values [][]interface{}
+			for _, cq := range db.queries {
+				values = append(values, []interface{}{cq.name, cq.query})
+			}
+			series = append(series, fakeSeries(db.name, []string{"name", "query"}, values)...)
+		}
+		return series, nil
+
+	case s.match("CREATE", "USER", "*", "WITH", "PASSWORD", "*"), s.match("CREATE", "USER", "*", "WITH", "PASSWORD", "*", "WITH", "ALL", "PRIVILEGES"):
+		admin := len(w) > 6
+		if u, ok := f.users[w[2]]; ok {
+			if u.password != w[5] || u.admin != admin {
+				return nil, fmt.Errorf("user already exists")
+			}
+			return nil, nil
+		}
+		f.users[w[2]] = &fakeInfluxUser{password: w[5], admin: admin, privileges: map[string]string{}}
+
+	case s.match("DROP", "USER", "*"):
+		if _, err := f.lookupUser(w[2]); err != nil {
+			return nil, err
+		}
+		delete(f.users, w[2])
+
+	case s.match("SET", "PASSWORD", "FOR", "*", "=", "*"):
+		u, err := f.lookupUser(w[3])
+		if err != nil {
+			return nil, err
+		}
+		u.password = w[5]
+
+	case s.match("GRANT", "ALL", "PRIVILEGES", "TO", "*"), s.match("GRANT", "ALL", "TO", "*"):
+		u, err := f.lookupUser(w[len(w)-1])
+		if err != nil {
+			return nil, err
+		}
+		u.admin = true
+
+	case s.match("REVOKE", "ALL", "PRIVILEGES", "FROM", "*"), s.match("REVOKE", "ALL", "FROM", "*"):
+		u, err := f.lookupUser(w[len(w)-1])
+		if err != nil {
+			return nil, err
+		}
+		u.admin = false
+
+	case s.match("GRANT", "*", "ON", "*", "TO", "*"):
+		u, err := f.lookupUser(w[5])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.lookupDatabase(w[3]); err != nil {
+			return nil, err
+		}
+		u.privileges[w[3]] = strings.ToUpper(w[1])
+
+	case s.match("REVOKE", "*", "ON", "*", "FROM", "*"):
+		u, err := f.lookupUser(w[5])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.lookupDatabase(w[3]); err != nil {
+			return nil, err
+		}
+		// The revoked privilege is cleared from the one the user holds.
+		remaining := fakePrivileges[u.privileges[w[3]]] &^ fakePrivileges[strings.ToUpper(w[1])]
+		u.privileges[w[3]] = "NO PRIVILEGES"
+		for privilege, bits := range fakePrivileges {
+			if bits == remaining && bits != 0 {
+				u.privileges[w[3]] = privilege
+			}
+		}
+
+	case s.match("SHOW", "USERS"):
+		var names []string
+		for name := range f.users {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var values [][]interface{}
+		for _, name := range names {
+			values = append(values, []interface{}{name, f.users[name].admin})
+		}
+		return fakeSeries("", []string{"user", "admin"}, values), nil
+
+	case s.match("SHOW", "GRANTS", "FOR", "*"):
+		u, err := f.lookupUser(w[3])
+		if err != nil {
+			return nil, err
+		}
+		var values [][]interface{}
+		for _, grant := range flattenGrants(u.privileges) {
+			privilege := grant["privilege"]
+			if privilege == "ALL" {
+				privilege = "ALL PRIVILEGES"
+			}
+			values = append(values, []interface{}{grant["database"], privilege})
+		}
+		return fakeSeries("", []string{"database", "privilege"}, values), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", s.text)
+	}
+	return nil, nil
+}
+
+func (f *fakeInfluxQL) lookupUser(name string) (*fakeInfluxUser, error) {
+	u, ok := f.users[name]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return u, nil
+}
+
+func (f *fakeInfluxQL) findDatabase(name string) *fakeDatabase {
+	for _, db := range f.databases {
+		if db.name == name {
+			return db
+		}
+	}
+	return nil
+}
+
+func (f *fakeInfluxQL) lookupDatabase(name string) (*fakeDatabase, error) {
+	db := f.findDatabase(name)
+	if db == nil {
+		return nil, fmt.Errorf("database not found: %s", name)
+	}
+	return db, nil
+}
+
+// grants returns the privileges of the user, leaving out the databases it
+// no longer has any privileges on.
+func (u *fakeInfluxUser) grants() map[string]string {
+	grants := map[string]string{}
+	for database, privilege := range u.privileges {
+		if privilege != "NO PRIVILEGES" {
+			grants[database] = privilege
+		}
+	}
+	return grants
+}
+
+func (db *fakeDatabase) policy(name string) *fakeRetentionPolicy {
+	for _, rp := range db.policies {
+		if rp.name == name {
+			return rp
+		}
+	}
+	return nil
+}
+
+// setPolicy creates or alters a retention policy from the options of a
+// CREATE or ALTER RETENTION POLICY statement, validating them the way
+// InfluxDB does.
+func (db *fakeDatabase) setPolicy(name string, create bool, options []string) error {
+	existing := db.policy(name)
+	rp := fakeRetentionPolicy{name: name, replicaN: 1}
+	if existing != nil {
+		rp = *existing
+	} else if !create {
+		return fmt.Errorf("retention policy not found: %s", name)
+	}
+
+	var shardGroupDuration time.Duration
+	isDefault := false
+	for i := 0; i < len(options); i++ {
+		var err error
+		switch {
+		case strings.EqualFold(options[i], "DEFAULT"):
+			isDefault = true
+		case strings.EqualFold(options[i], "DURATION") && i+1 < len(options):
+			rp.duration, err = parseDuration(options[i+1])
+			i++
+		case strings.EqualFold(options[i], "REPLICATION") && i+1 < len(options):
+			rp.replicaN, err = strconv.Atoi(options[i+1])
+			i++
+		case strings.EqualFold(options[i], "SHARD") && i+2 < len(options) && strings.EqualFold(options[i+1], "DURATION"):
+			shardGroupDuration, err = parseDuration(options[i+2])
+			i += 2
+		default:
+			err = fmt.Errorf("found %s, expected DURATION, REPLICATION, SHARD or DEFAULT", options[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if shardGroupDuration != 0 {
+		rp.shardGroupDuration = shardGroupDuration
+	} else if existing == nil {
+		rp.shardGroupDuration = fakeShardGroupDuration(rp.duration)
+	}
+	if rp.duration != 0 && rp.duration < time.Hour {
+		return fmt.Errorf("retention policy duration must be at least 1h0m0s")
+	}
+	if rp.duration != 0 && rp.duration < rp.shardGroupDuration {
+		return fmt.Errorf("retention policy duration must be greater than the shard duration")
+	}
+
+	switch {
+	case existing == nil:
+		db.policies = append(db.policies, &rp)
+	case create && (rp != *existing || (isDefault && db.defaultPolicy != name)):
+		return fmt.Errorf("retention policy conflicts with an existing policy")
+	default:
+		*existing = rp
+	}
+	if isDefault {
+		db.defaultPolicy = name
+	}
+	return nil
+}
+
+// fakeShardGroupDuration returns the shard group duration InfluxDB picks for
+// a retention policy created without one.
+func fakeShardGroupDuration(d time.Duration) time.Duration {
+	switch {
+	case d > 0 && d < 2*day:
+		return time.Hour
+	case d > 0 && d < 180*day:
+		return day
+	}
+	return week
+}
+
+// formatContinuousQuery rewrites a CREATE CONTINUOUS QUERY statement the way
+// InfluxDB stores it, normalizing its SELECT statement and qualifying its
+// measurements with the database and its default retention policy.
+func (db *fakeDatabase) formatContinuousQuery(name, statement string) (string, error) {
+	query, resample, err := parseContinuousQuery(statement)
+	if err != nil {
+		return "", err
+	}
+	if db.defaultPolicy == "" {
+		return "", fmt.Errorf("default retention policy not set for: %s", db.name)
+	}
+
+	tokens := tokenizeQuery(query)
+	parts := make([]string, len(tokens))
+	measurement := false
+	for i, token := range tokens {
+		parts[i] = token.text
+		switch {
+		case token.kind == wordToken && (token.text == "FROM" || token.text == "INTO"):
+			measurement = true
+			continue
+		case token.kind == wordToken && influxqlKeywords[token.text]:
+			measurement = false
+		case measurement && token.kind != punctuationToken && !strings.HasPrefix(token.text, "/") &&
+			(i == 0 || tokens[i-1].text != ".") && (i+1 == len(tokens) || tokens[i+1].text != "."):
+			parts[i] = fakeIdentifier(db.name) + "." + fakeIdentifier(db.defaultPolicy) + "." + token.text
+		}
+	}
+
+	formatted := "CREATE CONTINUOUS QUERY " + fakeIdentifier(name) + " ON " + fakeIdentifier(db.name)
+	if resample != "" {
+		formatted += " RESAMPLE " + normalizeResample(resample)
+	}
+	return formatted + " BEGIN " + normalizeQuery(strings.Join(parts, " ")) + " END", nil
+}
+
+// fakeIdentifier quotes an identifier only when it needs to be, as InfluxDB
+// does when it formats statements.
+func fakeIdentifier(name string) string {
+	if bareIdentifierRegexp.MatchString(name) && !influxqlKeywords[strings.ToUpper(name)] {
+		return name
+	}
+	return quoteIdentifier(name)
+}
+
+// match reports whether the statement is made of the given words, compared
+// case-insensitively, where "*" stands for any single word.
+func (s fakeStatement) match(pattern ...string) bool {
+	return len(s.words) == len(pattern) && s.hasPrefix(pattern...)
+}
+
+// hasPrefix reports whether the statement starts with the given words,
+// matched as by match.
+func (s fakeStatement) hasPrefix(pattern ...string) bool {
+	if len(s.words) < len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && !strings.EqualFold(p, s.words[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFakeStatements splits a query into statements, reading back quoted
+// identifiers and strings and substituting bound parameters.
+func parseFakeStatements(query string, params map[string]interface{}) ([]fakeStatement, error) {
+	var statements []fakeStatement
+	var s fakeStatement
+	var text []string
+
+	tokens := tokenizeQuery(query)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		word := token.text
+		switch {
+		case token.text == ";":
+			if len(s.words) > 0 {
+				s.text = strings.Join(text, " ")
+				statements = append(statements, s)
+			}
+			s, text = fakeStatement{}, nil
+			continue
+
+		case token.text == "$" && i+1 < len(tokens):
+			i++
+			v, ok := params[tokens[i].text]
+			if !ok {
+				return nil, fmt.Errorf("missing parameter: %s", tokens[i].text)
+			}
+			word = fmt.Sprint(v)
+
+		case token.kind == quotedToken && !strings.HasPrefix(token.text, "/"):
+			var err error
+			if word, err = unquoteInfluxQL(token.text); err != nil {
+				return nil, err
+			}
+		}
+		s.words = append(s.words, word)
+		text = append(text, token.text)
+	}
+	if len(s.words) > 0 {
+		s.text = strings.Join(text, " ")
+		statements = append(statements, s)
+	}
+	return statements, nil
+}
+
+func fakeSeries(name string, columns []string, values [][]interface{}) []map[string]interface{} {
+	series := map[string]interface{}{"columns": columns}
+	if name != "" {
+		series["name"] = name
+	}
+	if len(values) > 0 {
+		series["values"] = values
+	}
+	return []map[string]interface{}{series}
+}
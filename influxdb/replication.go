@@ -0,0 +1,179 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/client"
+	"github.com/influxdata/influxdb/models"
+)
+
+// replicate sends q to every node, so that nodes whose writes are mirrored
+// by InfluxDB-Relay keep the same databases, users, retention policies and
+// continuous queries. The statement is sent to the remaining nodes even when
+// it fails on one, and the errors are reported with the node they come from.
+func (c *queryClient) replicate(q client.Query, params map[string]interface{}) (*client.Response, error) {
+	resps, err := c.queryNodes(q, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs nodeErrors
+	for i, resp := range resps {
+		if err := resp.Error(); err != nil {
+			errs = append(errs, nodeError{host: c.nodes[i].Host, err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return &client.Response{Err: errs}, nil
+	}
+	return resps[0], nil
+}
+
+// compareNodes runs the SHOW statements of q on every node and logs the
+// rows that differ from the first node. Only the rows found on every node
+// are returned, so that Terraform plans to apply again the objects missing
+// or modified on any node.
+func (c *queryClient) compareNodes(q client.Query, params map[string]interface{}) (*client.Response, error) {
+	resps, err := c.queryNodes(q, params)
+	if err != nil {
+		return nil, err
+	}
+	for i, resp := range resps {
+		if err := resp.Error(); err != nil {
+			return &client.Response{Err: nodeErrors{{host: c.nodes[i].Host, err: err}}}, nil
+		}
+	}
+
+	first := resps[0]
+	common := &client.Response{Results: make([]client.Result, len(first.Results))}
+	for i, result := range first.Results {
+		common.Results[i] = client.Result{Messages: result.Messages}
+		for _, series := range result.Series {
+			rows := series.Values
+			for n, resp := range resps[1:] {
+				other := findSeries(resp, i, series)
+				missing, unexpected := diffRows(series.Values, other)
+				if len(missing) > 0 || len(unexpected) > 0 {
+					log.Printf("[WARN] InfluxDB at %s differs from %s for %q: missing %s, unexpected %s",
+						c.nodes[n+1].Host, c.nodes[0].Host, q.Command, formatRows(missing), formatRows(unexpected))
+				}
+				rows = intersectRows(rows, other)
+			}
+
+			series.Values = rows
+			common.Results[i].Series = append(common.Results[i].Series, series)
+		}
+	}
+	return common, nil
+}
+
+// queryNodes sends q to every node in turn.
+func (c *queryClient) queryNodes(q client.Query, params map[string]interface{}) ([]*client.Response, error) {
+	resps := make([]*client.Response, len(c.nodes))
+	var errs nodeErrors
+	for i, node := range c.nodes {
+		node := node
+		err := c.retry.run(c.retries(q), func() (err error) {
+			resps[i], err = c.query(node, q, params)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, nodeError{host: node.Host, err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return resps, nil
+}
+
+// findSeries returns the rows of the series of the given result of resp with
+// the same name, tags and columns as series.
+func findSeries(resp *client.Response, result int, series models.Row) [][]interface{} {
+	if result >= len(resp.Results) {
+		return nil
+	}
+	for _, other := range resp.Results[result].Series {
+		if other.SameSeries(&series) && strings.Join(other.Columns, ",") == strings.Join(series.Columns, ",") {
+			return other.Values
+		}
+	}
+	return nil
+}
+
+// diffRows returns the rows of a missing from b, and the rows of b missing
+// from a.
+func diffRows(a, b [][]interface{}) (missing, unexpected [][]interface{}) {
+	return subtractRows(a, b), subtractRows(b, a)
+}
+
+func subtractRows(a, b [][]interface{}) [][]interface{} {
+	keys := rowKeys(b)
+	var rows [][]interface{}
+	for _, row := range a {
+		if !keys[rowKey(row)] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func intersectRows(a, b [][]interface{}) [][]interface{} {
+	keys := rowKeys(b)
+	var rows [][]interface{}
+	for _, row := range a {
+		if keys[rowKey(row)] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func rowKeys(rows [][]interface{}) map[string]bool {
+	keys := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		keys[rowKey(row)] = true
+	}
+	return keys
+}
+
+func rowKey(row []interface{}) string {
+	b, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Sprint(row)
+	}
+	return string(b)
+}
+
+func formatRows(rows [][]interface{}) string {
+	if len(rows) == 0 {
+		return "nothing"
+	}
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = rowKey(row)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// parseNodeURLs parses the urls setting of the provider.
+func parseNodeURLs(urls []interface{}) ([]url.URL, error) {
+	nodes := make([]url.URL, len(urls))
+	for i, raw := range urls {
+		u, err := url.Parse(raw.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid InfluxDB URL: %s", err)
+		}
+		if u.Scheme == "unix" {
+			return nil, fmt.Errorf("invalid InfluxDB URL %q: unix sockets are only supported in url", u)
+		}
+		nodes[i] = *u
+	}
+	return nodes, nil
+}
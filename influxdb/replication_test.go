@@ -0,0 +1,207 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// fakeNode is an InfluxDB node that only knows about databases.
+type fakeNode struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	databases map[string]bool
+	commands  []string
+}
+
+var fakeNodeDatabaseRegexp = regexp.MustCompile(`^(CREATE|DROP) DATABASE "(.*)"$`)
+
+func newFakeNode(databases ...string) *fakeNode {
+	n := &fakeNode{databases: make(map[string]bool)}
+	for _, db := range databases {
+		n.databases[db] = true
+	}
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		q := r.FormValue("q")
+		n.commands = append(n.commands, q)
+		result := map[string]interface{}{"statement_id": 0}
+		if q == "SHOW DATABASES" {
+			var values [][]string
+			for db := range n.databases {
+				values = append(values, []string{db})
+			}
+			sort.Slice(values, func(i, j int) bool { return values[i][0] < values[j][0] })
+			result["series"] = []map[string]interface{}{{"name": "databases", "columns": []string{"name"}, "values": values}}
+		} else if m := fakeNodeDatabaseRegexp.FindStringSubmatch(q); m != nil {
+			if m[1] == "CREATE" {
+				n.databases[m[2]] = true
+			} else if !n.databases[m[2]] {
+				result["error"] = "database not found: " + m[2]
+			} else {
+				delete(n.databases, m[2])
+			}
+		} else {
+			result["error"] = "unsupported statement"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": []interface{}{result}})
+	}))
+	return n
+}
+
+// deadNodeURL returns the URL of a server that no longer listens.
+func deadNodeURL() url.URL {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	u, _ := url.Parse(srv.URL)
+	return *u
+}
+
+func testQueryClient(replicateDDL bool, nodes ...url.URL) *queryClient {
+	return newQueryClient(nodes, queryAuth{}, http.DefaultClient, retryPolicy{
+		maxRetries: 1,
+		waitMin:    time.Millisecond,
+		waitMax:    time.Millisecond,
+	}, replicateDDL)
+}
+
+func nodeURL(n *fakeNode) url.URL {
+	u, _ := url.Parse(n.URL)
+	return *u
+}
+
+func TestQueryClient_failover(t *testing.T) {
+	dead := deadNodeURL()
+	node := newFakeNode()
+	defer node.Close()
+
+	c := testQueryClient(false, dead, nodeURL(node))
+	if err := exec(c, "CREATE DATABASE \"telegraf\""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.active != 1 {
+		t.Fatalf("expected the second node to become active, got %d", c.active)
+	}
+
+	// Statements that can't be retried fail over too, as they never
+	// reached the first node.
+	c.active = 0
+	if err := exec(c, "DROP DATABASE \"telegraf\""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(node.commands, []string{"CREATE DATABASE \"telegraf\"", "DROP DATABASE \"telegraf\""}) {
+		t.Fatalf("unexpected commands: %q", node.commands)
+	}
+}
+
+func TestQueryClient_allNodesDown(t *testing.T) {
+	first, second := deadNodeURL(), deadNodeURL()
+
+	err := exec(testQueryClient(false, first, second), "SHOW DATABASES")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, msg := range []string{"unable to connect to InfluxDB at " + first.Host + ", " + second.Host, first.Host + ": ", second.Host + ": "} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected the error to contain %q, got %q", msg, err)
+		}
+	}
+}
+
+func TestQueryClient_replicate(t *testing.T) {
+	first, second := newFakeNode("_internal"), newFakeNode("_internal", "telegraf")
+	defer first.Close()
+	defer second.Close()
+
+	c := testQueryClient(true, nodeURL(first), nodeURL(second))
+	if err := exec(c, "CREATE DATABASE \"metrics\""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, n := range []*fakeNode{first, second} {
+		if !n.databases["metrics"] {
+			t.Errorf("expected %s to have the metrics database", n.URL)
+		}
+	}
+
+	// telegraf is missing from the first node, so it is reported as missing
+	// to let Terraform create it again on both.
+	resp, err := c.Query(client.Query{Command: "SHOW DATABASES"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var databases []string
+	for _, row := range resp.Results[0].Series[0].Values {
+		databases = append(databases, row[0].(string))
+	}
+	if !reflect.DeepEqual(databases, []string{"_internal", "metrics"}) {
+		t.Fatalf("expected the databases of both nodes, got %q", databases)
+	}
+
+	// The statement is still applied to the second node, and the error
+	// names the node it comes from.
+	err = exec(c, "DROP DATABASE \"telegraf\"")
+	if err == nil || !strings.Contains(err.Error(), first.Listener.Addr().String()+": database not found: telegraf") {
+		t.Fatalf("expected an error from the first node, got %v", err)
+	}
+	if second.databases["telegraf"] {
+		t.Fatal("expected telegraf to be dropped from the second node")
+	}
+
+	// Other statements are only sent to the active node.
+	if _, err := c.Query(client.Query{Command: "SELECT * FROM cpu"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(second.commands) != 3 {
+		t.Fatalf("expected SELECT not to be sent to the second node, got %q", second.commands)
+	}
+}
+
+func TestQueryClient_replicateNodeDown(t *testing.T) {
+	node := newFakeNode()
+	defer node.Close()
+	dead := deadNodeURL()
+
+	c := testQueryClient(true, nodeURL(node), dead)
+	err := exec(c, "CREATE DATABASE \"metrics\"")
+	if err == nil || !strings.HasPrefix(err.Error(), dead.Host+": ") {
+		t.Fatalf("expected an error from the unreachable node, got %v", err)
+	}
+	if !node.databases["metrics"] {
+		t.Fatal("expected the statement to be applied to the reachable node")
+	}
+}
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	cases := map[string]bool{
+		"SHOW DATABASES":                        true,
+		"SELECT * FROM cpu; SHOW USERS":         true,
+		"SELECT mean(v) INTO m FROM cpu":        false,
+		"CREATE DATABASE \"a\"":                 false,
+		"SHOW USERS; DROP USER \"paul\"":        false,
+		"ALTER RETENTION POLICY \"a\" ON \"b\"": false,
+		"":                                      false,
+	}
+	for command, expected := range cases {
+		if actual := isReadOnlyCommand(command); actual != expected {
+			t.Errorf("%q: expected %t, got %t", command, expected, actual)
+		}
+	}
+}
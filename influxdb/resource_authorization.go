@@ -0,0 +1,237 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// authorization is an InfluxDB 2.x API token as returned by
+// /api/v2/authorizations.
+type authorization struct {
+	ID          string       `json:"id,omitempty"`
+	OrgID       string       `json:"orgID"`
+	Description string       `json:"description"`
+	Status      string       `json:"status,omitempty"`
+	Permissions []permission `json:"permissions"`
+	Token       string       `json:"token,omitempty"`
+}
+
+type permission struct {
+	Action   string             `json:"action"`
+	Resource permissionResource `json:"resource"`
+}
+
+type permissionResource struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	OrgID string `json:"orgID,omitempty"`
+}
+
+func resourceAuthorization() *schema.Resource {
+	return &schema.Resource{
+		Create: createAuthorization,
+		Read:   readAuthorization,
+		Update: updateAuthorization,
+		Delete: deleteAuthorization,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "active",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+					switch value {
+					case "active", "inactive":
+					default:
+						errors = append(errors, fmt.Errorf(
+							"%q must be one of following values: (active|inactive)", k))
+					}
+					return
+				},
+			},
+			"permissions": {
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"action": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+							ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+								value := v.(string)
+								switch value {
+								case "read", "write":
+								default:
+									errors = append(errors, fmt.Errorf(
+										"%q must be one of following values: (read|write)", k))
+								}
+								return
+							},
+						},
+						"resource": {
+							Type:     schema.TypeList,
+							Required: true,
+							ForceNew: true,
+							MaxItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:     schema.TypeString,
+										Required: true,
+										ForceNew: true,
+									},
+									"id": {
+										Type:     schema.TypeString,
+										Optional: true,
+										ForceNew: true,
+									},
+									// The server fills in the organization
+									// of the authorization when it is left
+									// out.
+									"org_id": {
+										Type:     schema.TypeString,
+										Optional: true,
+										Computed: true,
+										ForceNew: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"token": {
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func expandPermissions(v []interface{}) []permission {
+	var permissions []permission
+	for _, vv := range v {
+		p := vv.(map[string]interface{})
+		resource := p["resource"].([]interface{})[0].(map[string]interface{})
+		permissions = append(permissions, permission{
+			Action: p["action"].(string),
+			Resource: permissionResource{
+				Type:  resource["type"].(string),
+				ID:    resource["id"].(string),
+				OrgID: resource["org_id"].(string),
+			},
+		})
+	}
+	return permissions
+}
+
+func flattenPermissions(permissions []permission) []map[string]interface{} {
+	var result = []map[string]interface{}{}
+	for _, p := range permissions {
+		result = append(result, map[string]interface{}{
+			"action": p.Action,
+			"resource": []map[string]interface{}{
+				{
+					"type":   p.Resource.Type,
+					"id":     p.Resource.ID,
+					"org_id": p.Resource.OrgID,
+				},
+			},
+		})
+	}
+	return result
+}
+
+func createAuthorization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	orgID, err := api.orgID(d.Get("org_id").(string))
+	if err != nil {
+		return err
+	}
+
+	auth := authorization{
+		OrgID:       orgID,
+		Description: d.Get("description").(string),
+		Status:      d.Get("status").(string),
+		Permissions: expandPermissions(d.Get("permissions").([]interface{})),
+	}
+	if err := api.do("POST", "/authorizations", nil, &auth, &auth); err != nil {
+		return err
+	}
+
+	// The token is only kept from the creation response: some servers
+	// redact it when it is read back, and it can't be recovered on import.
+	d.SetId(auth.ID)
+	d.Set("token", auth.Token)
+
+	return readAuthorization(d, meta)
+}
+
+func readAuthorization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	var auth authorization
+	if err := api.do("GET", "/authorizations/"+d.Id(), nil, nil, &auth); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("org_id", auth.OrgID)
+	d.Set("description", auth.Description)
+	d.Set("status", auth.Status)
+	if err := d.Set("permissions", flattenPermissions(auth.Permissions)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func updateAuthorization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if d.HasChange("description") || d.HasChange("status") {
+		update := map[string]string{
+			"description": d.Get("description").(string),
+			"status":      d.Get("status").(string),
+		}
+		if err := api.do("PATCH", "/authorizations/"+d.Id(), nil, update, nil); err != nil {
+			return err
+		}
+	}
+
+	return readAuthorization(d, meta)
+}
+
+func deleteAuthorization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if err := api.do("DELETE", "/authorizations/"+d.Id(), nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
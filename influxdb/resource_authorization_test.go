@@ -0,0 +1,164 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestInfluxDBAuthorization(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "authorizations", "influxdb_authorization"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testAuthorizationConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "authorizations", "influxdb_authorization.test", "description", "telegraf"),
+					resource.TestCheckResourceAttrSet(
+						"influxdb_authorization.test", "token",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_authorization.test", "permissions.#", "1",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_authorization.test", "permissions.0.action", "write",
+					),
+					resource.TestCheckResourceAttrPair(
+						"influxdb_authorization.test", "permissions.0.resource.0.id",
+						"influxdb_bucket.test", "id",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_authorization.test", "status", "active",
+					),
+					testCheckAuthorizationToken(api, "influxdb_authorization.test"),
+				),
+			},
+			{
+				Config: api.providerConfig() + testAuthorizationInactiveConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "authorizations", "influxdb_authorization.test", "status", "inactive"),
+					testCheckAPIObject(api, "authorizations", "influxdb_authorization.test", "description", "telegraf (disabled)"),
+					testCheckAuthorizationToken(api, "influxdb_authorization.test"),
+				),
+			},
+			{
+				Config:                  api.providerConfig() + testAuthorizationInactiveConfig,
+				ResourceName:            "influxdb_authorization.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"token"},
+			},
+		},
+	})
+}
+
+func TestInfluxDBAuthorization_defaultOrgID(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "authorizations", "influxdb_authorization"),
+		Steps: []resource.TestStep{
+			{
+				// The server fills in the organization of the resource,
+				// which mustn't replace the authorization on the next plan.
+				Config: api.providerConfig() + testAuthorizationDefaultOrgIDConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"influxdb_authorization.test", "permissions.0.resource.0.org_id",
+						"influxdb_bucket.test", "org_id",
+					),
+					testCheckAuthorizationToken(api, "influxdb_authorization.test"),
+				),
+			},
+		},
+	})
+}
+
+var testAuthorizationConfig = `
+resource "influxdb_bucket" "test" {
+  name = "terraform-test"
+}
+
+resource "influxdb_authorization" "test" {
+  description = "telegraf"
+
+  permissions {
+    action = "write"
+
+    resource {
+      type   = "buckets"
+      id     = "${influxdb_bucket.test.id}"
+      org_id = "${influxdb_bucket.test.org_id}"
+    }
+  }
+}
+`
+
+var testAuthorizationInactiveConfig = `
+resource "influxdb_bucket" "test" {
+  name = "terraform-test"
+}
+
+resource "influxdb_authorization" "test" {
+  description = "telegraf (disabled)"
+  status      = "inactive"
+
+  permissions {
+    action = "write"
+
+    resource {
+      type   = "buckets"
+      id     = "${influxdb_bucket.test.id}"
+      org_id = "${influxdb_bucket.test.org_id}"
+    }
+  }
+}
+`
+
+// testCheckAuthorizationToken checks that the token kept in state is the one
+// the server generated, and that updates didn't replace the authorization.
+func testCheckAuthorizationToken(api *fakeAPI, n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		doc := api.get("authorizations", rs.Primary.ID)
+		if doc == nil {
+			return fmt.Errorf("authorization %q does not exist", rs.Primary.ID)
+		}
+		if token := rs.Primary.Attributes["token"]; token != doc["token"] {
+			return fmt.Errorf("expected token %v, got %q", doc["token"], token)
+		}
+
+		return nil
+	}
+}
+
+var testAuthorizationDefaultOrgIDConfig = `
+resource "influxdb_bucket" "test" {
+  name = "terraform-test"
+}
+
+resource "influxdb_authorization" "test" {
+  description = "telegraf"
+
+  permissions {
+    action = "read"
+
+    resource {
+      type = "buckets"
+      id   = "${influxdb_bucket.test.id}"
+    }
+  }
+}
+`
@@ -0,0 +1,183 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// bucket is an InfluxDB 2.x bucket as returned by /api/v2/buckets.
+type bucket struct {
+	ID             string          `json:"id,omitempty"`
+	OrgID          string          `json:"orgID"`
+	Name           string          `json:"name"`
+	Description    string          `json:"description"`
+	RetentionRules []retentionRule `json:"retentionRules"`
+	SchemaType     string          `json:"schemaType,omitempty"`
+}
+
+// retentionRule is the 2.x counterpart of the duration and shard group
+// duration of a retention policy.
+type retentionRule struct {
+	Type                      string `json:"type"`
+	EverySeconds              int    `json:"everySeconds"`
+	ShardGroupDurationSeconds int    `json:"shardGroupDurationSeconds,omitempty"`
+}
+
+func resourceBucket() *schema.Resource {
+	return &schema.Resource{
+		Create: createBucket,
+		Read:   readBucket,
+		Update: updateBucket,
+		Delete: deleteBucket,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"retention_rules": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"every_seconds": {
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+						"shard_group_duration_seconds": {
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"schema_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+					switch value {
+					case "implicit", "explicit":
+					default:
+						errors = append(errors, fmt.Errorf(
+							"%q must be one of following values: (implicit|explicit)", k))
+					}
+					return
+				},
+			},
+		},
+	}
+}
+
+func expandBucket(d *schema.ResourceData) bucket {
+	b := bucket{
+		OrgID:          d.Get("org_id").(string),
+		Name:           d.Get("name").(string),
+		Description:    d.Get("description").(string),
+		RetentionRules: []retentionRule{},
+		SchemaType:     d.Get("schema_type").(string),
+	}
+
+	for _, v := range d.Get("retention_rules").([]interface{}) {
+		rule := v.(map[string]interface{})
+		b.RetentionRules = append(b.RetentionRules, retentionRule{
+			Type:                      "expire",
+			EverySeconds:              rule["every_seconds"].(int),
+			ShardGroupDurationSeconds: rule["shard_group_duration_seconds"].(int),
+		})
+	}
+
+	return b
+}
+
+func createBucket(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	b := expandBucket(d)
+	orgID, err := api.orgID(b.OrgID)
+	if err != nil {
+		return err
+	}
+	b.OrgID = orgID
+
+	if err := api.do("POST", "/buckets", nil, &b, &b); err != nil {
+		return err
+	}
+
+	d.SetId(b.ID)
+
+	return readBucket(d, meta)
+}
+
+func readBucket(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	var b bucket
+	if err := api.do("GET", "/buckets/"+d.Id(), nil, nil, &b); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	var rules = []map[string]interface{}{}
+	for _, rule := range b.RetentionRules {
+		rules = append(rules, map[string]interface{}{
+			"every_seconds":                rule.EverySeconds,
+			"shard_group_duration_seconds": rule.ShardGroupDurationSeconds,
+		})
+	}
+
+	d.Set("name", b.Name)
+	d.Set("org_id", b.OrgID)
+	d.Set("description", b.Description)
+	d.Set("schema_type", b.SchemaType)
+	if err := d.Set("retention_rules", rules); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func updateBucket(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if d.HasChange("name") || d.HasChange("description") || d.HasChange("retention_rules") {
+		b := expandBucket(d)
+		if err := api.do("PATCH", "/buckets/"+d.Id(), nil, &b, nil); err != nil {
+			return err
+		}
+	}
+
+	return readBucket(d, meta)
+}
+
+func deleteBucket(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if err := api.do("DELETE", "/buckets/"+d.Id(), nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestInfluxDBBucket_explicitSchema(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "buckets", "influxdb_bucket"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testBucketExplicitSchemaConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "buckets", "influxdb_bucket.test", "schemaType", "explicit"),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "retention_rules.#", "0",
+					),
+				),
+			},
+		},
+	})
+}
+
+func TestInfluxDBBucket(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "buckets", "influxdb_bucket"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testBucketConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "buckets", "influxdb_bucket.test", "name", "terraform-test"),
+					testCheckAPIObject(api, "buckets", "influxdb_bucket.test", "orgID", api.defaultOrg),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "org_id", api.defaultOrg,
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "retention_rules.0.every_seconds", "86400",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "retention_rules.0.shard_group_duration_seconds", "3600",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "schema_type", "implicit",
+					),
+				),
+			},
+			{
+				Config: api.providerConfig() + testBucketUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "buckets", "influxdb_bucket.test", "description", "one week"),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "retention_rules.0.every_seconds", "604800",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_bucket.test", "retention_rules.0.shard_group_duration_seconds", "7200",
+					),
+				),
+			},
+			{
+				Config:            api.providerConfig() + testBucketUpdateConfig,
+				ResourceName:      "influxdb_bucket.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+var testBucketConfig = `
+resource "influxdb_bucket" "test" {
+  name = "terraform-test"
+
+  retention_rules {
+    every_seconds = 86400
+  }
+}
+`
+
+var testBucketUpdateConfig = `
+resource "influxdb_bucket" "test" {
+  name        = "terraform-test"
+  description = "one week"
+
+  retention_rules {
+    every_seconds                = 604800
+    shard_group_duration_seconds = 7200
+  }
+}
+`
+
+var testBucketExplicitSchemaConfig = `
+resource "influxdb_bucket" "test" {
+  name        = "terraform-test"
+  schema_type = "explicit"
+}
+`
@@ -2,11 +2,9 @@ package influxdb
 
 import (
 	"fmt"
-	"strconv"
+	"strings"
 
-	"encoding/json"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/influxdata/influxdb/client"
 )
 
 func resourceDatabase() *schema.Resource {
@@ -15,6 +13,9 @@ func resourceDatabase() *schema.Resource {
 		Read:   readDatabase,
 		Delete: deleteDatabase,
 		Update: updateDatabase,
+		Importer: &schema.ResourceImporter{
+			State: importDatabase,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -33,8 +34,10 @@ func resourceDatabase() *schema.Resource {
 							Required: true,
 						},
 						"duration": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:             schema.TypeString,
+							Required:         true,
+							DiffSuppressFunc: suppressEquivalentDurations,
+							ValidateFunc:     validateDuration,
 						},
 						"replication": {
 							Type:     schema.TypeInt,
@@ -42,9 +45,11 @@ func resourceDatabase() *schema.Resource {
 							Default:  1,
 						},
 						"shardgroupduration": {
-							Type:     schema.TypeString,
-							Optional: true,
-							Default:  "",
+							Type:             schema.TypeString,
+							Optional:         true,
+							Default:          "",
+							DiffSuppressFunc: suppressDefaultShardGroupDuration,
+							ValidateFunc:     validateOptionalDuration,
 						},
 						"default": {
 							Type:     schema.TypeBool,
@@ -59,21 +64,12 @@ func resourceDatabase() *schema.Resource {
 }
 
 func createDatabase(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 
 	name := d.Get("name").(string)
-	queryStr := fmt.Sprintf("CREATE DATABASE %s", quoteIdentifier(name))
-	query := client.Query{
-		Command: queryStr,
-	}
-
-	resp, err := conn.Query(query)
-	if err != nil {
+	if err := exec(conn, fmt.Sprintf("CREATE DATABASE %s", quoteIdentifier(name))); err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
 	d.SetId(name)
 
@@ -90,7 +86,14 @@ func createDatabase(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func createRetentionPolicy(conn *client.Client, policyName string, duration string, replication int, shardGroupDuration string, defaultPolicy bool, database string) error {
+func createRetentionPolicy(conn *queryClient, policyName string, duration string, replication int, shardGroupDuration string, defaultPolicy bool, database string) error {
+	if err := checkDuration(duration); err != nil {
+		return err
+	}
+	if err := checkDuration(shardGroupDuration); err != nil {
+		return err
+	}
+
 	var shardDuration string
 
 	if shardGroupDuration != "" {
@@ -104,7 +107,14 @@ func createRetentionPolicy(conn *client.Client, policyName string, duration stri
 	}
 }
 
-func updateRetentionPolicy(conn *client.Client, policyName string, duration string, replication int, shardGroupDuration string, defaultPolicy bool, database string) error {
+func updateRetentionPolicy(conn *queryClient, policyName string, duration string, replication int, shardGroupDuration string, defaultPolicy bool, database string) error {
+	if err := checkDuration(duration); err != nil {
+		return err
+	}
+	if err := checkDuration(shardGroupDuration); err != nil {
+		return err
+	}
+
 	var shardDuration string
 
 	if shardGroupDuration != "" {
@@ -118,33 +128,26 @@ func updateRetentionPolicy(conn *client.Client, policyName string, duration stri
 	}
 }
 
-func deleteRetentionPolicy(conn *client.Client, policyName string, database string) error {
+func deleteRetentionPolicy(conn *queryClient, policyName string, database string) error {
 	return exec(conn, fmt.Sprintf("DROP RETENTION POLICY %s ON %s", quoteIdentifier(policyName), quoteIdentifier(database)))
 }
 
 func readDatabase(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 	name := d.Id()
 
 	// InfluxDB doesn't have a command to check the existence of a single
 	// database, so we instead must read the list of all databases and see
 	// if ours is present in it.
-	query := client.Query{
-		Command: "SHOW DATABASES",
-	}
-
-	resp, err := conn.Query(query)
+	databases, err := listDatabases(conn)
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
-	for _, result := range resp.Results[0].Series[0].Values {
-		if result[0] == name {
-			readRetentionPolicies(d, meta)
-			return nil
+	for _, database := range databases {
+		if database == name {
+			d.Set("name", name)
+			return readRetentionPolicies(d, conn, name)
 		}
 	}
 
@@ -154,58 +157,152 @@ func readDatabase(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func readRetentionPolicies(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
-	name := d.Get("name").(string)
+func listDatabases(conn *queryClient) ([]string, error) {
+	rows, err := showRows(conn, "SHOW DATABASES")
+	if err != nil {
+		return nil, err
+	}
 
-	query := client.Query{
-		Command: fmt.Sprintf("SHOW RETENTION POLICIES ON %s", name),
+	var databases []string
+	for _, row := range rows {
+		name, err := row.string("name")
+		if err != nil {
+			return nil, err
+		}
+		databases = append(databases, name)
 	}
 
-	resp, err := conn.Query(query)
+	return databases, nil
+}
+
+// retentionPolicy is a single row of SHOW RETENTION POLICIES output.
+type retentionPolicy struct {
+	name               string
+	duration           string
+	shardGroupDuration string
+	replication        int
+	isDefault          bool
+}
+
+func listRetentionPolicies(conn *queryClient, database string) ([]retentionPolicy, error) {
+	rows, err := showRows(conn, fmt.Sprintf("SHOW RETENTION POLICIES ON %s", quoteIdentifier(database)))
+	if err != nil {
+		// The policies are gone along with their database.
+		if strings.Contains(err.Error(), "database not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var policies []retentionPolicy
+	for _, row := range rows {
+		policy, err := decodeRetentionPolicy(row)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func decodeRetentionPolicy(row showRow) (retentionPolicy, error) {
+	var policy retentionPolicy
+	var err error
+
+	if policy.name, err = row.string("name"); err != nil {
+		return policy, err
+	}
+	if policy.duration, err = row.string("duration"); err != nil {
+		return policy, err
+	}
+	if policy.shardGroupDuration, err = row.string("shardGroupDuration"); err != nil {
+		return policy, err
+	}
+	if policy.replication, err = row.int("replicaN"); err != nil {
+		return policy, err
+	}
+	if policy.isDefault, err = row.bool("default"); err != nil {
+		return policy, err
+	}
+
+	return policy, nil
+}
+
+func readRetentionPolicies(d *schema.ResourceData, conn *queryClient, database string) error {
+	policies, err := listRetentionPolicies(conn, database)
 	if err != nil {
 		return err
 	}
 
-	if resp.Err != nil {
-		return resp.Err
+	policiesByName := make(map[string]retentionPolicy)
+	for _, policy := range policies {
+		policiesByName[policy.name] = policy
 	}
 
-	var retentionPolicies = []map[string]string{}
+	// Only refresh the policies we already track, in the order they are
+	// configured, so that policies managed by influxdb_retention_policy
+	// resources or created outside of Terraform are left alone.
+	var retentionPolicies = []map[string]interface{}{}
+	for _, v := range d.Get("retention_policies").([]interface{}) {
+		policyName := v.(map[string]interface{})["name"].(string)
+		if policy, ok := policiesByName[policyName]; ok {
+			retentionPolicies = append(retentionPolicies, flattenRetentionPolicy(policy))
+		}
+	}
 
-	if resp.Results[0].Err == nil {
-		for _, result := range resp.Results[0].Series[0].Values {
-			var retentionPolicy = map[string]string{
-				"name":               result[0].(string),
-				"duration":           result[1].(string),
-				"shardGroupDuration": result[2].(string),
-				"replicaN":           result[3].(json.Number).String(),
-				"default":            strconv.FormatBool(result[4].(bool)),
-			}
-			retentionPolicies = append(retentionPolicies, retentionPolicy)
+	return d.Set("retention_policies", retentionPolicies)
+}
+
+// importDatabase adopts every retention policy of the database except for
+// the "autogen" policy InfluxDB creates along with it.
+func importDatabase(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	conn := meta.(*providerMeta).conn
+
+	policies, err := listRetentionPolicies(conn, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	var retentionPolicies = []map[string]interface{}{}
+	for _, policy := range policies {
+		if policy.name != "autogen" {
+			retentionPolicies = append(retentionPolicies, flattenRetentionPolicy(policy))
 		}
 	}
+	if err := d.Set("retention_policies", retentionPolicies); err != nil {
+		return nil, err
+	}
 
-	d.Set("retention_policies", retentionPolicies)
-	return nil
+	return []*schema.ResourceData{d}, nil
 }
 
-func deleteDatabase(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
-	name := d.Id()
+func flattenRetentionPolicy(policy retentionPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"name":               policy.name,
+		"duration":           normalizeDuration(policy.duration),
+		"shardgroupduration": normalizeDuration(policy.shardGroupDuration),
+		"replication":        policy.replication,
+		"default":            policy.isDefault,
+	}
+}
 
-	queryStr := fmt.Sprintf("DROP DATABASE %s", quoteIdentifier(name))
-	query := client.Query{
-		Command: queryStr,
+// suppressDefaultShardGroupDuration ignores the shard group duration chosen
+// by the server when none is configured.
+func suppressDefaultShardGroupDuration(k, old, new string, d *schema.ResourceData) bool {
+	if new == "" {
+		return true
 	}
+	return suppressEquivalentDurations(k, old, new, d)
+}
 
-	resp, err := conn.Query(query)
-	if err != nil {
+func deleteDatabase(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+	name := d.Id()
+
+	if err := exec(conn, fmt.Sprintf("DROP DATABASE %s", quoteIdentifier(name))); err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
 	d.SetId("")
 
@@ -213,7 +310,7 @@ func deleteDatabase(d *schema.ResourceData, meta interface{}) error {
 }
 
 func updateDatabase(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 	name := d.Get("name").(string)
 
 	if d.HasChange("retention_policies") {
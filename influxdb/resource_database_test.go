@@ -1,13 +1,13 @@
 package influxdb
 
 import (
-	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	"github.com/influxdata/influxdb/client"
 )
 
 func TestAccInfluxDBDatabase(t *testing.T) {
@@ -23,6 +23,11 @@ func TestAccInfluxDBDatabase(t *testing.T) {
 					),
 				),
 			},
+			{
+				ResourceName:      "influxdb_database.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -56,10 +61,140 @@ func TestAccInfluxDBDatabaseWithRPs(t *testing.T) {
 					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "1week", "168h0m0s", "1", "1h0m0s", false),
 				),
 			},
+			{
+				ResourceName:      "influxdb_database.rptest",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
 
+func TestInfluxDBDatabase(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckFakeDatabaseDestroyed(fake, "terraform-rp-test"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccDatabaseWithRPSConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseExists("influxdb_database.rptest"),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "1day", "24h0m0s", "1", "1h0m0s", true),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "52weeks", "8736h0m0s", "1", "168h0m0s", false),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "1week", "168h0m0s", "1", "1h0m0s", false),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "autogen", "0s", "1", "168h0m0s", false),
+				),
+			},
+			{
+				Config:            fake.providerConfig() + testAccDatabaseWithRPSConfig,
+				ResourceName:      "influxdb_database.rptest",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicyNonExisting("influxdb_database.rptest", "name", "52weeks"),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "2days", "48h0m0s", "1", "", false),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "12weeks", "2016h0m0s", "1", "", true),
+					resource.TestCheckResourceAttr(
+						"influxdb_database.rptest", "retention_policies.#", "3",
+					),
+				),
+			},
+			{
+				// Policies changed outside of Terraform are altered back.
+				PreConfig: func() {
+					fake.run(t, `ALTER RETENTION POLICY "1week" ON "terraform-rp-test" DURATION 2w REPLICATION 2`)
+				},
+				Config:             fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				Check:  testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "1week", "168h0m0s", "1", "1h0m0s", false),
+			},
+			{
+				// Policies created outside of Terraform are left alone.
+				PreConfig: func() {
+					fake.run(t, `CREATE RETENTION POLICY "other" ON "terraform-rp-test" DURATION 1d REPLICATION 1`)
+				},
+				Config:   fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				PlanOnly: true,
+			},
+			{
+				PreConfig:          func() { fake.run(t, `DROP DATABASE "terraform-rp-test"`) },
+				Config:             fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccDatabaseWithRPSUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckDatabaseExists("influxdb_database.rptest"),
+					testAccCheckRetentionPolicy("influxdb_database.rptest", "terraform-rp-test", "12weeks", "2016h0m0s", "1", "", true),
+				),
+			},
+		},
+	})
+}
+
+func TestInfluxDBDatabase_errors(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				PreConfig:   func() { fake.fail(`^CREATE DATABASE`, "timeout") },
+				Config:      fake.providerConfig() + testAccDatabaseConfig,
+				ExpectError: regexp.MustCompile(`timeout`),
+			},
+			{
+				// The database wasn't recorded as created.
+				Config:             fake.providerConfig() + testAccDatabaseConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig() + testAccDatabaseConfig,
+				Check:     testAccCheckDatabaseExists("influxdb_database.test"),
+			},
+			{
+				PreConfig:   func() { fake.fail(`^DROP DATABASE`, "timeout") },
+				Config:      fake.providerConfig(),
+				ExpectError: regexp.MustCompile(`timeout`),
+			},
+			{
+				// The database is still in the state, so it is dropped
+				// again.
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig(),
+				Check:     testCheckFakeDatabaseDestroyed(fake, "terraform-test"),
+			},
+		},
+	})
+}
+
+// testCheckFakeDatabaseDestroyed checks that the fake server no longer has
+// the given databases.
+func testCheckFakeDatabaseDestroyed(fake *fakeInfluxQL, names ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, name := range names {
+			if fake.database(name) != nil {
+				return fmt.Errorf("Database %q still exists", name)
+			}
+		}
+		return nil
+	}
+}
+
 func testAccCheckDatabaseExists(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -71,23 +206,15 @@ func testAccCheckDatabaseExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No database id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: "SHOW DATABASES",
-		}
-
-		resp, err := conn.Query(query)
+		databases, err := listDatabases(conn)
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0] == rs.Primary.Attributes["name"] {
+		for _, database := range databases {
+			if database == rs.Primary.Attributes["name"] {
 				return nil
 			}
 		}
@@ -107,23 +234,15 @@ func testAccCheckRetentionPolicyNonExisting(n, database, policyName string) reso
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
-
-		query := client.Query{
-			Command: fmt.Sprintf("SHOW RETENTION POLICIES ON \"%s\"", rs.Primary.Attributes["name"]),
-		}
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		resp, err := conn.Query(query)
+		policies, err := listRetentionPolicies(conn, rs.Primary.Attributes["name"])
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0].(string) == policyName {
+		for _, policy := range policies {
+			if policy.name == policyName {
 				return fmt.Errorf("Retention Policy %q on %q for %q exists", policyName, database, rs.Primary.Attributes["name"])
 			}
 		}
@@ -143,30 +262,22 @@ func testAccCheckRetentionPolicy(n, database, policyName, duration, replication
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: fmt.Sprintf("SHOW RETENTION POLICIES ON \"%s\"", rs.Primary.Attributes["name"]),
-		}
-
-		resp, err := conn.Query(query)
+		policies, err := listRetentionPolicies(conn, rs.Primary.Attributes["name"])
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0].(string) == policyName {
-				if result[1].(string) != duration {
+		for _, policy := range policies {
+			if policy.name == policyName {
+				if policy.duration != duration {
 					return fmt.Errorf("Duration %q on retention Policy %q on %q for %q does not match", duration, policyName, database, rs.Primary.Attributes["name"])
-				} else if shardGroupDuration != "" && result[2].(string) != shardGroupDuration {
+				} else if shardGroupDuration != "" && policy.shardGroupDuration != shardGroupDuration {
 					return fmt.Errorf("ShardGroupDuration %q on retention Policy %q on %q for %q does not match", shardGroupDuration, policyName, database, rs.Primary.Attributes["name"])
-				} else if result[3].(json.Number).String() != replication {
+				} else if strconv.Itoa(policy.replication) != replication {
 					return fmt.Errorf("Replication %q on retention Policy %q on %q for %q does not match", replication, policyName, database, rs.Primary.Attributes["name"])
-				} else if result[4].(bool) != isDefault {
+				} else if policy.isDefault != isDefault {
 					return fmt.Errorf("Default %v on retention Policy %q on %q for %q does not match", isDefault, policyName, database, rs.Primary.Attributes["name"])
 				}
 				return nil
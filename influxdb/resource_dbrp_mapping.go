@@ -0,0 +1,176 @@
+package influxdb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// dbrpMapping maps an InfluxDB 1.x database and retention policy to a 2.x
+// bucket, as returned by /api/v2/dbrps.
+type dbrpMapping struct {
+	ID              string `json:"id,omitempty"`
+	OrgID           string `json:"orgID,omitempty"`
+	BucketID        string `json:"bucketID,omitempty"`
+	Database        string `json:"database,omitempty"`
+	RetentionPolicy string `json:"retention_policy"`
+	Default         bool   `json:"default"`
+}
+
+func resourceDBRPMapping() *schema.Resource {
+	return &schema.Resource{
+		Create: createDBRPMapping,
+		Read:   readDBRPMapping,
+		Update: updateDBRPMapping,
+		Delete: deleteDBRPMapping,
+		Importer: &schema.ResourceImporter{
+			State: importDBRPMapping,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"retention_policy": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"bucket_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"default": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+// dbrpParams returns the query parameters scoping a request for a mapping to
+// its organization, which the /api/v2/dbrps endpoints require.
+func dbrpParams(d *schema.ResourceData, api *apiClient) (url.Values, error) {
+	orgID, err := api.orgID(d.Get("org_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	return url.Values{"orgID": {orgID}}, nil
+}
+
+func createDBRPMapping(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	orgID, err := api.orgID(d.Get("org_id").(string))
+	if err != nil {
+		return err
+	}
+
+	m := dbrpMapping{
+		OrgID:           orgID,
+		BucketID:        d.Get("bucket_id").(string),
+		Database:        d.Get("database").(string),
+		RetentionPolicy: d.Get("retention_policy").(string),
+		Default:         d.Get("default").(bool),
+	}
+	if err := api.do("POST", "/dbrps", nil, &m, &m); err != nil {
+		return err
+	}
+
+	d.SetId(m.ID)
+	d.Set("org_id", orgID)
+
+	return readDBRPMapping(d, meta)
+}
+
+func readDBRPMapping(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	params, err := dbrpParams(d, api)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Content dbrpMapping `json:"content"`
+	}
+	if err := api.do("GET", "/dbrps/"+d.Id(), params, nil, &resp); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	m := resp.Content
+	d.Set("database", m.Database)
+	d.Set("retention_policy", m.RetentionPolicy)
+	d.Set("bucket_id", m.BucketID)
+	d.Set("org_id", m.OrgID)
+	d.Set("default", m.Default)
+
+	return nil
+}
+
+func updateDBRPMapping(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if d.HasChange("retention_policy") || d.HasChange("default") {
+		params, err := dbrpParams(d, api)
+		if err != nil {
+			return err
+		}
+
+		m := dbrpMapping{
+			RetentionPolicy: d.Get("retention_policy").(string),
+			Default:         d.Get("default").(bool),
+		}
+		if err := api.do("PATCH", "/dbrps/"+d.Id(), params, &m, nil); err != nil {
+			return err
+		}
+	}
+
+	return readDBRPMapping(d, meta)
+}
+
+func deleteDBRPMapping(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	params, err := dbrpParams(d, api)
+	if err != nil {
+		return err
+	}
+
+	if err := api.do("DELETE", "/dbrps/"+d.Id(), params, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// importDBRPMapping accepts either the ID of a mapping in the provider's
+// organization, or <org_id>/<id> for a mapping in any other.
+func importDBRPMapping(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if parts := strings.Split(d.Id(), "/"); len(parts) == 2 {
+		if parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid ID %q, expected <org_id>/<id>", d.Id())
+		}
+		d.Set("org_id", parts[0])
+		d.SetId(parts[1])
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
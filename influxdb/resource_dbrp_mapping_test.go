@@ -0,0 +1,72 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestInfluxDBDBRPMapping(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "dbrps", "influxdb_dbrp_mapping"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testDBRPMappingConfig("autogen", false),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "dbrps", "influxdb_dbrp_mapping.test", "database", "terraform-test"),
+					testCheckAPIObject(api, "dbrps", "influxdb_dbrp_mapping.test", "retention_policy", "autogen"),
+					testCheckAPIObject(api, "dbrps", "influxdb_dbrp_mapping.test", "orgID", api.defaultOrg),
+					resource.TestCheckResourceAttrPair(
+						"influxdb_dbrp_mapping.test", "bucket_id", "influxdb_bucket.test", "id",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_dbrp_mapping.test", "org_id", api.defaultOrg,
+					),
+				),
+			},
+			{
+				Config: api.providerConfig() + testDBRPMappingConfig("one_week", true),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "dbrps", "influxdb_dbrp_mapping.test", "retention_policy", "one_week"),
+					testCheckAPIObject(api, "dbrps", "influxdb_dbrp_mapping.test", "default", true),
+					resource.TestCheckResourceAttr(
+						"influxdb_dbrp_mapping.test", "default", "true",
+					),
+				),
+			},
+			{
+				Config:            api.providerConfig() + testDBRPMappingConfig("one_week", true),
+				ResourceName:      "influxdb_dbrp_mapping.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config:              api.providerConfig() + testDBRPMappingConfig("one_week", true),
+				ResourceName:        "influxdb_dbrp_mapping.test",
+				ImportState:         true,
+				ImportStateIdPrefix: api.defaultOrg + "/",
+				ImportStateVerify:   true,
+			},
+		},
+	})
+}
+
+func testDBRPMappingConfig(retentionPolicy string, isDefault bool) string {
+	return fmt.Sprintf(`
+resource "influxdb_bucket" "test" {
+  name = "terraform-test"
+}
+
+resource "influxdb_dbrp_mapping" "test" {
+  database         = "terraform-test"
+  retention_policy = "%s"
+  bucket_id        = "${influxdb_bucket.test.id}"
+  default          = %t
+}
+`, retentionPolicy, isDefault)
+}
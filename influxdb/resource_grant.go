@@ -0,0 +1,132 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceGrant() *schema.Resource {
+	return &schema.Resource{
+		Create: createGrant,
+		Read:   readGrant,
+		Update: updateGrant,
+		Delete: deleteGrant,
+		Importer: &schema.ResourceImporter{
+			State: importGrant,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"user": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privilege": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validatePrivilege,
+			},
+		},
+	}
+}
+
+func createGrant(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	user := d.Get("user").(string)
+	database := d.Get("database").(string)
+
+	if err := grantPrivilegeOn(conn, d.Get("privilege").(string), database, user); err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", database, user))
+
+	return readGrant(d, meta)
+}
+
+func readGrant(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	// The ID can't be split back reliably as names may contain a "/".
+	user := d.Get("user").(string)
+	database := d.Get("database").(string)
+
+	privileges, err := listGrants(conn, user)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	privilege, ok := privileges[database]
+	if !ok {
+		// If we fell out here then the user has no privileges on the
+		// database anymore.
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("privilege", privilege)
+
+	return nil
+}
+
+func importGrant(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	conn := meta.(*providerMeta).conn
+
+	database, user, err := resolveDatabaseScopedID(d.Id(), func(database, user string) (bool, error) {
+		privileges, err := listGrants(conn, user)
+		if err != nil {
+			if strings.Contains(err.Error(), "user not found") {
+				return false, nil
+			}
+			return false, err
+		}
+		_, ok := privileges[database]
+		return ok, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("user", user)
+	d.Set("database", database)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func updateGrant(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	if d.HasChange("privilege") {
+		// Granting a privilege on a database replaces the one the user
+		// currently holds on it.
+		if err := grantPrivilegeOn(conn, d.Get("privilege").(string), d.Get("database").(string), d.Get("user").(string)); err != nil {
+			return err
+		}
+	}
+
+	return readGrant(d, meta)
+}
+
+func deleteGrant(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	if err := revokePrivilegeOn(conn, "ALL", d.Get("database").(string), d.Get("user").(string)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
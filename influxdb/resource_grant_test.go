@@ -0,0 +1,240 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccInfluxDBGrant(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGrantDestroyed("terraform-grant-blue", "terraform_grant_test"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGrantConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserGrants("influxdb_user.test", "terraform-grant-green", "READ"),
+					testAccCheckUserGrants("influxdb_user.test", "terraform-grant-blue", "WRITE"),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.blue", "id", "terraform-grant-blue/terraform_grant_test",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.blue", "privilege", "WRITE",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "grant.#", "1",
+					),
+				),
+			},
+			{
+				Config: testAccGrantUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserGrants("influxdb_user.test", "terraform-grant-green", "READ"),
+					testAccCheckUserGrants("influxdb_user.test", "terraform-grant-blue", "ALL PRIVILEGES"),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.blue", "privilege", "ALL",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "grant.#", "1",
+					),
+				),
+			},
+			{
+				ResourceName:      "influxdb_grant.blue",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestInfluxDBGrant(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGrantDestroyed("terraform-grant-blue", "terraform_grant_test"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccGrantConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "terraform_grant_test", false, "terraform-grant-green", "READ", "terraform-grant-blue", "WRITE"),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.blue", "id", "terraform-grant-blue/terraform_grant_test",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "grant.#", "1",
+					),
+				),
+			},
+			{
+				Config: fake.providerConfig() + testAccGrantUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserGrants("influxdb_user.test", "terraform-grant-blue", "ALL PRIVILEGES"),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.blue", "privilege", "ALL",
+					),
+				),
+			},
+			{
+				Config:            fake.providerConfig() + testAccGrantUpdateConfig,
+				ResourceName:      "influxdb_grant.blue",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// Partially revoking the privilege leaves WRITE behind.
+				PreConfig: func() {
+					fake.run(t, `REVOKE READ ON "terraform-grant-blue" FROM terraform_grant_test`)
+				},
+				Config:             fake.providerConfig() + testAccGrantUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccGrantUpdateConfig,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_grant_test", false, "terraform-grant-green", "READ", "terraform-grant-blue", "ALL"),
+			},
+			{
+				// Revoking it entirely leaves NO PRIVILEGES behind.
+				PreConfig: func() {
+					fake.run(t, `REVOKE ALL ON "terraform-grant-blue" FROM terraform_grant_test`)
+				},
+				Config:             fake.providerConfig() + testAccGrantUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccGrantUpdateConfig,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_grant_test", false, "terraform-grant-green", "READ", "terraform-grant-blue", "ALL"),
+			},
+		},
+	})
+}
+
+// TestInfluxDBGrant_slashes covers names containing a "/", which make the
+// "<database>/<user>" ID ambiguous.
+func TestInfluxDBGrant_slashes(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGrantDestroyed("terraform/grant", "svc/writer"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccGrantSlashConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "svc/writer", false, "terraform/grant", "WRITE"),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.test", "user", "svc/writer",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_grant.test", "database", "terraform/grant",
+					),
+				),
+			},
+			{
+				Config:   fake.providerConfig() + testAccGrantSlashConfig,
+				PlanOnly: true,
+			},
+			{
+				Config:            fake.providerConfig() + testAccGrantSlashConfig,
+				ResourceName:      "influxdb_grant.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckGrantDestroyed(database, user string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*providerMeta).conn
+
+		privileges, err := listGrants(conn, user)
+		if err != nil {
+			// The user has been dropped along with all of its grants.
+			return nil
+		}
+
+		if privilege, ok := privileges[database]; ok {
+			return fmt.Errorf("Privilege %q on %q for %q still exists", privilege, database, user)
+		}
+
+		return nil
+	}
+}
+
+var testAccGrantConfig = `
+resource "influxdb_database" "green" {
+    name = "terraform-grant-green"
+}
+
+resource "influxdb_database" "blue" {
+    name = "terraform-grant-blue"
+}
+
+resource "influxdb_user" "test" {
+    name = "terraform_grant_test"
+    password = "terraform"
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+}
+
+resource "influxdb_grant" "blue" {
+    user = "${influxdb_user.test.name}"
+    database = "${influxdb_database.blue.name}"
+    privilege = "WRITE"
+}
+`
+
+var testAccGrantUpdateConfig = `
+resource "influxdb_database" "green" {
+    name = "terraform-grant-green"
+}
+
+resource "influxdb_database" "blue" {
+    name = "terraform-grant-blue"
+}
+
+resource "influxdb_user" "test" {
+    name = "terraform_grant_test"
+    password = "terraform"
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+}
+
+resource "influxdb_grant" "blue" {
+    user = "${influxdb_user.test.name}"
+    database = "${influxdb_database.blue.name}"
+    privilege = "ALL"
+}
+`
+
+var testAccGrantSlashConfig = `
+resource "influxdb_database" "test" {
+    name = "terraform/grant"
+}
+
+resource "influxdb_user" "test" {
+    name = "svc/writer"
+    password = "terraform"
+}
+
+resource "influxdb_grant" "test" {
+    user = "${influxdb_user.test.name}"
+    database = "${influxdb_database.test.name}"
+    privilege = "WRITE"
+}
+`
@@ -0,0 +1,127 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// orgMembership is a user's role in an organization as returned by
+// /api/v2/orgs/{id}/members and /api/v2/orgs/{id}/owners.
+type orgMembership struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// resourceOrgMembership returns the resource managing the members or the
+// owners of an organization, depending on the endpoint given.
+func resourceOrgMembership(endpoint string) *schema.Resource {
+	return &schema.Resource{
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			return createOrgMembership(d, meta, endpoint)
+		},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			return readOrgMembership(d, meta, endpoint)
+		},
+		Delete: func(d *schema.ResourceData, meta interface{}) error {
+			return deleteOrgMembership(d, meta, endpoint)
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+			"user_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func createOrgMembership(d *schema.ResourceData, meta interface{}, endpoint string) error {
+	api := meta.(*providerMeta).api
+
+	orgID, err := api.orgID(d.Get("org_id").(string))
+	if err != nil {
+		return err
+	}
+	userID := d.Get("user_id").(string)
+
+	member := orgMembership{ID: userID}
+	if err := api.do("POST", fmt.Sprintf("/orgs/%s/%s", orgID, endpoint), nil, &member, nil); err != nil {
+		return err
+	}
+
+	d.SetId(orgID + "/" + userID)
+
+	return readOrgMembership(d, meta, endpoint)
+}
+
+func readOrgMembership(d *schema.ResourceData, meta interface{}, endpoint string) error {
+	api := meta.(*providerMeta).api
+
+	orgID, userID, err := parseOrgMembershipID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Users []orgMembership `json:"users"`
+	}
+	if err := api.do("GET", fmt.Sprintf("/orgs/%s/%s", orgID, endpoint), nil, nil, &resp); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	for _, member := range resp.Users {
+		if member.ID == userID {
+			d.Set("org_id", orgID)
+			d.Set("user_id", userID)
+			return nil
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func deleteOrgMembership(d *schema.ResourceData, meta interface{}, endpoint string) error {
+	api := meta.(*providerMeta).api
+
+	orgID, userID, err := parseOrgMembershipID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/orgs/%s/%s/%s", orgID, endpoint, userID)
+	if err := api.do("DELETE", path, nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// parseOrgMembershipID splits an ID of the form <org_id>/<user_id>.
+func parseOrgMembershipID(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid ID %q, expected <org_id>/<user_id>", id)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,108 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestInfluxDBOrgMembership(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckOrgMembershipDestroyed(api),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testOrgMembershipConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckOrgMembership(api, "influxdb_org_member.test", "members"),
+					testCheckOrgMembership(api, "influxdb_org_owner.test", "owners"),
+					testCheckOrgMembership(api, "influxdb_org_member.default", "members"),
+					resource.TestCheckResourceAttr(
+						"influxdb_org_member.default", "org_id", api.defaultOrg,
+					),
+				),
+			},
+			{
+				Config:            api.providerConfig() + testOrgMembershipConfig,
+				ResourceName:      "influxdb_org_owner.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testCheckOrgMembership(api *fakeAPI, n, endpoint string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		orgID, userID, err := parseOrgMembershipID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		if !api.hasRole(orgID, endpoint, userID) {
+			return fmt.Errorf("user %q is not in the %s of organization %q", userID, endpoint, orgID)
+		}
+
+		return nil
+	}
+}
+
+func testCheckOrgMembershipDestroyed(api *fakeAPI) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			endpoint := map[string]string{
+				"influxdb_org_member": "members",
+				"influxdb_org_owner":  "owners",
+			}[rs.Type]
+			if endpoint == "" {
+				continue
+			}
+
+			orgID, userID, err := parseOrgMembershipID(rs.Primary.ID)
+			if err != nil {
+				return err
+			}
+			if api.hasRole(orgID, endpoint, userID) {
+				return fmt.Errorf("user %q is still in the %s of organization %q", userID, endpoint, orgID)
+			}
+		}
+		return nil
+	}
+}
+
+var testOrgMembershipConfig = `
+resource "influxdb_organization" "test" {
+  name = "terraform-test"
+}
+
+resource "influxdb_v2_user" "member" {
+  name = "member"
+}
+
+resource "influxdb_v2_user" "owner" {
+  name = "owner"
+}
+
+resource "influxdb_org_member" "test" {
+  org_id  = "${influxdb_organization.test.id}"
+  user_id = "${influxdb_v2_user.member.id}"
+}
+
+resource "influxdb_org_owner" "test" {
+  org_id  = "${influxdb_organization.test.id}"
+  user_id = "${influxdb_v2_user.owner.id}"
+}
+
+resource "influxdb_org_member" "default" {
+  user_id = "${influxdb_v2_user.member.id}"
+}
+`
@@ -0,0 +1,117 @@
+package influxdb
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// organization is an InfluxDB 2.x organization as returned by /api/v2/orgs.
+type organization struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func resourceOrganization() *schema.Resource {
+	return &schema.Resource{
+		Create: createOrganization,
+		Read:   readOrganization,
+		Update: updateOrganization,
+		Delete: deleteOrganization,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func createOrganization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	org := organization{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+	}
+	if err := api.do("POST", "/orgs", nil, &org, &org); err != nil {
+		return err
+	}
+
+	d.SetId(org.ID)
+
+	return readOrganization(d, meta)
+}
+
+func readOrganization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	var org organization
+	if err := api.do("GET", "/orgs/"+d.Id(), nil, nil, &org); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", org.Name)
+	d.Set("description", org.Description)
+
+	return nil
+}
+
+func updateOrganization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if d.HasChange("name") || d.HasChange("description") {
+		org := organization{
+			Name:        d.Get("name").(string),
+			Description: d.Get("description").(string),
+		}
+		if err := api.do("PATCH", "/orgs/"+d.Id(), nil, &org, nil); err != nil {
+			return err
+		}
+	}
+
+	return readOrganization(d, meta)
+}
+
+func deleteOrganization(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if err := api.do("DELETE", "/orgs/"+d.Id(), nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// findOrganization looks up an organization by name.
+func findOrganization(api *apiClient, name string) (*organization, error) {
+	var resp struct {
+		Orgs []organization `json:"orgs"`
+	}
+	if err := api.do("GET", "/orgs", url.Values{"org": {name}}, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, org := range resp.Orgs {
+		if org.Name == name {
+			return &org, nil
+		}
+	}
+	return nil, fmt.Errorf("organization %q not found", name)
+}
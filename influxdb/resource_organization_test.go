@@ -0,0 +1,86 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestInfluxDBOrganization(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "orgs", "influxdb_organization"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testOrganizationConfig("terraform-test", "Managed by Terraform"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "orgs", "influxdb_organization.test", "name", "terraform-test"),
+					resource.TestCheckResourceAttr(
+						"influxdb_organization.test", "description", "Managed by Terraform",
+					),
+				),
+			},
+			{
+				Config: api.providerConfig() + testOrganizationConfig("terraform-renamed", ""),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "orgs", "influxdb_organization.test", "name", "terraform-renamed"),
+					testCheckAPIObject(api, "orgs", "influxdb_organization.test", "description", ""),
+				),
+			},
+			{
+				Config:            api.providerConfig() + testOrganizationConfig("terraform-renamed", ""),
+				ResourceName:      "influxdb_organization.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testCheckAPIObject checks a property of the document the fake API stores
+// for a resource.
+func testCheckAPIObject(api *fakeAPI, collection, n, property string, expected interface{}) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		doc := api.get(collection, rs.Primary.ID)
+		if doc == nil {
+			return fmt.Errorf("%s %q does not exist", collection, rs.Primary.ID)
+		}
+		if actual := fmt.Sprintf("%v", doc[property]); actual != fmt.Sprintf("%v", expected) {
+			return fmt.Errorf("expected %s of %s %q to be %v, got %s", property, collection, rs.Primary.ID, expected, actual)
+		}
+
+		return nil
+	}
+}
+
+// testCheckAPIObjectDestroyed checks that the fake API no longer stores any
+// of the resources of the given type.
+func testCheckAPIObjectDestroyed(api *fakeAPI, collection, resourceType string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type == resourceType && api.get(collection, rs.Primary.ID) != nil {
+				return fmt.Errorf("%s %q still exists", collection, rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func testOrganizationConfig(name, description string) string {
+	return fmt.Sprintf(`
+resource "influxdb_organization" "test" {
+  name        = "%s"
+  description = "%s"
+}
+`, name, description)
+}
@@ -0,0 +1,147 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceRetentionPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: createRetentionPolicyResource,
+		Read:   readRetentionPolicyResource,
+		Update: updateRetentionPolicyResource,
+		Delete: deleteRetentionPolicyResource,
+		Importer: &schema.ResourceImporter{
+			State: importRetentionPolicy,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"duration": {
+				Type:             schema.TypeString,
+				Required:         true,
+				DiffSuppressFunc: suppressEquivalentDurations,
+				ValidateFunc:     validateDuration,
+			},
+			"replication": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  1,
+			},
+			"shard_duration": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				DiffSuppressFunc: suppressEquivalentDurations,
+				ValidateFunc:     validateOptionalDuration,
+			},
+			"default": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func createRetentionPolicyResource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+
+	if err := createRetentionPolicy(conn, name, d.Get("duration").(string), d.Get("replication").(int), d.Get("shard_duration").(string), d.Get("default").(bool), database); err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", database, name))
+
+	return readRetentionPolicyResource(d, meta)
+}
+
+func readRetentionPolicyResource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	// The ID can't be split back reliably as names may contain a "/".
+	database := d.Get("database").(string)
+	name := d.Get("name").(string)
+
+	policies, err := listRetentionPolicies(conn, database)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if policy.name == name {
+			d.Set("duration", normalizeDuration(policy.duration))
+			d.Set("replication", policy.replication)
+			d.Set("shard_duration", normalizeDuration(policy.shardGroupDuration))
+			d.Set("default", policy.isDefault)
+			return nil
+		}
+	}
+
+	// If we fell out here then either the policy or its database is gone.
+	d.SetId("")
+
+	return nil
+}
+
+func importRetentionPolicy(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	conn := meta.(*providerMeta).conn
+
+	database, name, err := resolveDatabaseScopedID(d.Id(), func(database, name string) (bool, error) {
+		policies, err := listRetentionPolicies(conn, database)
+		if err != nil {
+			return false, err
+		}
+		for _, policy := range policies {
+			if policy.name == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("database", database)
+	d.Set("name", name)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func updateRetentionPolicyResource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	if d.HasChange("duration") || d.HasChange("replication") || d.HasChange("shard_duration") || d.HasChange("default") {
+		if err := updateRetentionPolicy(conn, d.Get("name").(string), d.Get("duration").(string), d.Get("replication").(int), d.Get("shard_duration").(string), d.Get("default").(bool), d.Get("database").(string)); err != nil {
+			return err
+		}
+	}
+
+	return readRetentionPolicyResource(d, meta)
+}
+
+func deleteRetentionPolicyResource(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+
+	if err := deleteRetentionPolicy(conn, d.Get("name").(string), d.Get("database").(string)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccInfluxDBRetentionPolicy(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckRetentionPolicyDestroyed("terraform-rp-resource-test", "2days"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRetentionPolicyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "48h0m0s", "1", "1h0m0s", false),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "id", "terraform-rp-resource-test/2days",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "duration", "2d",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "shard_duration", "1h",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "default", "false",
+					),
+				),
+			},
+			{
+				Config: testAccRetentionPolicyUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "72h0m0s", "1", "2h0m0s", true),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "duration", "3d",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "default", "true",
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_database.test", "retention_policies.#", "1",
+					),
+				),
+			},
+			{
+				ResourceName:      "influxdb_retention_policy.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestInfluxDBRetentionPolicy(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckFakeDatabaseDestroyed(fake, "terraform-rp-resource-test"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccRetentionPolicyConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "48h0m0s", "1", "1h0m0s", false),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "shard_duration", "1h",
+					),
+				),
+			},
+			{
+				Config: fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "72h0m0s", "1", "2h0m0s", true),
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "autogen", "0s", "1", "168h0m0s", false),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "duration", "3d",
+					),
+				),
+			},
+			{
+				Config:            fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				ResourceName:      "influxdb_retention_policy.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				PreConfig: func() {
+					fake.run(t, `ALTER RETENTION POLICY "2days" ON "terraform-rp-resource-test" SHARD DURATION 1d`)
+				},
+				Config:             fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// Making another policy the default is drift as well.
+				PreConfig: func() {
+					fake.run(t, `ALTER RETENTION POLICY "2days" ON "terraform-rp-resource-test" SHARD DURATION 2h; ALTER RETENTION POLICY "autogen" ON "terraform-rp-resource-test" DEFAULT`)
+				},
+				Config:             fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				Check:  testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "72h0m0s", "1", "2h0m0s", true),
+			},
+			{
+				PreConfig:          func() { fake.run(t, `DROP RETENTION POLICY "2days" ON "terraform-rp-resource-test"`) },
+				Config:             fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccRetentionPolicyUpdateConfig,
+				Check:  testAccCheckRetentionPolicy("influxdb_database.test", "terraform-rp-resource-test", "2days", "72h0m0s", "1", "2h0m0s", true),
+			},
+		},
+	})
+}
+
+// TestInfluxDBRetentionPolicy_slashes covers names containing a "/", which
+// make the "<database>/<name>" ID ambiguous.
+func TestInfluxDBRetentionPolicy_slashes(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckFakeDatabaseDestroyed(fake, "terraform/rp"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccRetentionPolicySlashConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckRetentionPolicy("influxdb_database.test", "terraform/rp", "2/days", "48h0m0s", "1", "1h0m0s", false),
+					resource.TestCheckResourceAttr(
+						"influxdb_retention_policy.test", "name", "2/days",
+					),
+				),
+			},
+			{
+				Config:   fake.providerConfig() + testAccRetentionPolicySlashConfig,
+				PlanOnly: true,
+			},
+			{
+				Config:            fake.providerConfig() + testAccRetentionPolicySlashConfig,
+				ResourceName:      "influxdb_retention_policy.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckRetentionPolicyDestroyed(database, policyName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		conn := testAccProvider.Meta().(*providerMeta).conn
+
+		policies, err := listRetentionPolicies(conn, database)
+		if err != nil {
+			return err
+		}
+
+		for _, policy := range policies {
+			if policy.name == policyName {
+				return fmt.Errorf("Retention Policy %q on %q still exists", policyName, database)
+			}
+		}
+
+		return nil
+	}
+}
+
+var testAccRetentionPolicyConfig = `
+resource "influxdb_database" "test" {
+	name = "terraform-rp-resource-test"
+	retention_policies {
+		name = "1week"
+		duration = "1w"
+	}
+}
+
+resource "influxdb_retention_policy" "test" {
+	database = "${influxdb_database.test.name}"
+	name = "2days"
+	duration = "2d"
+	shard_duration = "1h"
+}
+`
+
+var testAccRetentionPolicyUpdateConfig = `
+resource "influxdb_database" "test" {
+	name = "terraform-rp-resource-test"
+	retention_policies {
+		name = "1week"
+		duration = "1w"
+	}
+}
+
+resource "influxdb_retention_policy" "test" {
+	database = "${influxdb_database.test.name}"
+	name = "2days"
+	duration = "72h"
+	shard_duration = "2h"
+	default = true
+}
+`
+
+var testAccRetentionPolicySlashConfig = `
+resource "influxdb_database" "test" {
+	name = "terraform/rp"
+}
+
+resource "influxdb_retention_policy" "test" {
+	database = "${influxdb_database.test.name}"
+	name = "2/days"
+	duration = "2d"
+	shard_duration = "1h"
+}
+`
@@ -0,0 +1,230 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// task is an InfluxDB 2.x Flux task as returned by /api/v2/tasks. The server
+// derives Name, Every, Cron and Offset from the task option of the script.
+type task struct {
+	ID          string `json:"id,omitempty"`
+	OrgID       string `json:"orgID,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description"`
+	Status      string `json:"status,omitempty"`
+	Flux        string `json:"flux"`
+	Every       string `json:"every,omitempty"`
+	Cron        string `json:"cron,omitempty"`
+	Offset      string `json:"offset,omitempty"`
+}
+
+var (
+	taskOptionRegexp   = regexp.MustCompile(`(?m)^[ \t]*option[ \t]+task[ \t]*=[ \t]*\{[^}]*\}[ \t]*\n?`)
+	fluxDurationRegexp = regexp.MustCompile(`^(\d+(ns|us|µs|ms|s|mo|m|h|d|w|y))+$`)
+)
+
+func resourceTask() *schema.Resource {
+	return &schema.Resource{
+		Create: createTask,
+		Read:   readTask,
+		Update: updateTask,
+		Delete: deleteTask,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		CustomizeDiff: func(d *schema.ResourceDiff, meta interface{}) error {
+			if !d.NewValueKnown("every") || !d.NewValueKnown("cron") {
+				return nil
+			}
+			if d.Get("every").(string) == "" && d.Get("cron").(string) == "" {
+				return fmt.Errorf("one of every or cron must be set")
+			}
+			return nil
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"flux": {
+				Type:     schema.TypeString,
+				Required: true,
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					if taskOptionRegexp.MatchString(v.(string)) {
+						errors = append(errors, fmt.Errorf(
+							"%q must not set the task option, it is generated from name, every, cron and offset", k))
+					}
+					return
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeFlux(old) == normalizeFlux(new)
+				},
+			},
+			"every": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"cron"},
+				ValidateFunc:  validateFluxDuration,
+			},
+			"cron": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"every"},
+			},
+			"offset": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateFluxDuration,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "active",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+					switch value {
+					case "active", "inactive":
+					default:
+						errors = append(errors, fmt.Errorf(
+							"%q must be one of following values: (active|inactive)", k))
+					}
+					return
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"org_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func validateFluxDuration(v interface{}, k string) (ws []string, errors []error) {
+	if !fluxDurationRegexp.MatchString(v.(string)) {
+		errors = append(errors, fmt.Errorf("%q must be a Flux duration such as 30m or 1h", k))
+	}
+	return
+}
+
+// taskScript prepends the task option built from the schedule settings to the
+// configured script, which is how tasks are scheduled in Flux.
+func taskScript(d *schema.ResourceData) string {
+	options := []string{fmt.Sprintf("name: %s", fluxString(d.Get("name").(string)))}
+	if every := d.Get("every").(string); every != "" {
+		options = append(options, "every: "+every)
+	}
+	if cron := d.Get("cron").(string); cron != "" {
+		options = append(options, "cron: "+fluxString(cron))
+	}
+	if offset := d.Get("offset").(string); offset != "" {
+		options = append(options, "offset: "+offset)
+	}
+
+	return fmt.Sprintf("option task = {%s}\n\n%s", strings.Join(options, ", "), strings.TrimLeft(d.Get("flux").(string), "\n"))
+}
+
+// fluxString returns s as a Flux string literal.
+func fluxString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `${`, `\${`).Replace(s) + `"`
+}
+
+// normalizeFlux removes the differences in line endings and trailing
+// whitespace that editors and the server introduce into scripts.
+func normalizeFlux(s string) string {
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func createTask(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	orgID, err := api.orgID(d.Get("org_id").(string))
+	if err != nil {
+		return err
+	}
+
+	t := task{
+		OrgID:       orgID,
+		Description: d.Get("description").(string),
+		Status:      d.Get("status").(string),
+		Flux:        taskScript(d),
+	}
+	if err := api.do("POST", "/tasks", nil, &t, &t); err != nil {
+		return err
+	}
+
+	d.SetId(t.ID)
+
+	return readTask(d, meta)
+}
+
+func readTask(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	var t task
+	if err := api.do("GET", "/tasks/"+d.Id(), nil, nil, &t); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", t.Name)
+	d.Set("every", t.Every)
+	d.Set("cron", t.Cron)
+	d.Set("offset", t.Offset)
+	d.Set("status", t.Status)
+	d.Set("description", t.Description)
+	d.Set("org_id", t.OrgID)
+
+	// Keep the configured spelling of the script unless it was changed
+	// outside of Terraform.
+	flux := strings.TrimLeft(taskOptionRegexp.ReplaceAllString(t.Flux, ""), "\n")
+	if normalizeFlux(flux) != normalizeFlux(d.Get("flux").(string)) {
+		d.Set("flux", flux)
+	}
+
+	return nil
+}
+
+func updateTask(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	t := task{
+		Description: d.Get("description").(string),
+		Status:      d.Get("status").(string),
+		Flux:        taskScript(d),
+	}
+	if err := api.do("PATCH", "/tasks/"+d.Id(), nil, &t, nil); err != nil {
+		return err
+	}
+
+	return readTask(d, meta)
+}
+
+func deleteTask(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if err := api.do("DELETE", "/tasks/"+d.Id(), nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestInfluxDBTask(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "tasks", "influxdb_task"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testTaskConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "name", "downsample"),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "every", "1h"),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "offset", "5m"),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "status", "active"),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "orgID", api.defaultOrg),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "flux",
+						"option task = {name: \"downsample\", every: 1h, offset: 5m}\n\n"+testTaskScript),
+				),
+			},
+			{
+				Config: api.providerConfig() + testTaskUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "name", "downsample \"daily\""),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "cron", "0 0 * * *"),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "every", nil),
+					testCheckAPIObject(api, "tasks", "influxdb_task.test", "status", "inactive"),
+					resource.TestCheckResourceAttr(
+						"influxdb_task.test", "every", "",
+					),
+				),
+			},
+			{
+				// The script is edited outside of Terraform.
+				PreConfig: func() {
+					api.updateAll("tasks", map[string]interface{}{
+						"flux": "option task = {name: \"downsample \\\"daily\\\"\", cron: \"0 0 * * *\"}\n\nfrom(bucket: \"other\")\n",
+					})
+				},
+				Config:             api.providerConfig() + testTaskUpdateConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: api.providerConfig() + testTaskUpdateConfig,
+				Check: testCheckAPIObject(api, "tasks", "influxdb_task.test", "flux",
+					"option task = {name: \"downsample \\\"daily\\\"\", cron: \"0 0 * * *\"}\n\n"+testTaskScript),
+			},
+			{
+				Config:            api.providerConfig() + testTaskUpdateConfig,
+				ResourceName:      "influxdb_task.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestInfluxDBTask_noSchedule(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + `
+resource "influxdb_task" "test" {
+  name = "downsample"
+  flux = "from(bucket: \"metrics\")"
+}
+`,
+				ExpectError: regexp.MustCompile("one of every or cron must be set"),
+			},
+		},
+	})
+}
+
+func TestNormalizeFlux(t *testing.T) {
+	cases := []struct {
+		a, b string
+	}{
+		{"from(bucket: \"a\")\n", "from(bucket: \"a\")"},
+		{"from(bucket: \"a\")  \r\n  |> range(start: -1h)", "from(bucket: \"a\")\n  |> range(start: -1h)\n\n"},
+	}
+
+	for _, tc := range cases {
+		if normalizeFlux(tc.a) != normalizeFlux(tc.b) {
+			t.Errorf("expected %q and %q to be equivalent", tc.a, tc.b)
+		}
+	}
+
+	if normalizeFlux("from(bucket: \"a\")") == normalizeFlux("from(bucket: \"b\")") {
+		t.Errorf("expected scripts reading different buckets to differ")
+	}
+}
+
+const testTaskScript = `from(bucket: "metrics")
+  |> range(start: -task.every)
+  |> aggregateWindow(every: 1m, fn: mean)
+  |> to(bucket: "downsampled")
+`
+
+var testTaskConfig = fmt.Sprintf(`
+resource "influxdb_task" "test" {
+  name   = "downsample"
+  every  = "1h"
+  offset = "5m"
+
+  flux = <<EOF
+%sEOF
+}
+`, testTaskScript)
+
+var testTaskUpdateConfig = fmt.Sprintf(`
+resource "influxdb_task" "test" {
+  name   = "downsample \"daily\""
+  cron   = "0 0 * * *"
+  status = "inactive"
+
+  flux = <<EOF
+%sEOF
+}
+`, testTaskScript)
@@ -2,10 +2,10 @@ package influxdb
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/influxdata/influxdb/client"
 )
 
 func resourceUser() *schema.Resource {
@@ -14,6 +14,9 @@ func resourceUser() *schema.Resource {
 		Read:   readUser,
 		Update: updateUser,
 		Delete: deleteUser,
+		Importer: &schema.ResourceImporter{
+			State: importUser,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -24,10 +27,16 @@ func resourceUser() *schema.Resource {
 			"password": {
 				Type:      schema.TypeString,
 				Required:  true,
-				ForceNew:  true,
 				Sensitive: true,
 				StateFunc: hashSum,
 			},
+			// password_version sets the password again when it changes, for
+			// passwords that are rotated without their value changing in
+			// the configuration.
+			"password_version": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
 			"admin": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -43,18 +52,9 @@ func resourceUser() *schema.Resource {
 							Required: true,
 						},
 						"privilege": {
-							Type:     schema.TypeString,
-							Required: true,
-							ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
-								value := v.(string)
-								switch value {
-								case "READ", "WRITE", "ALL":
-								default:
-									errors = append(errors, fmt.Errorf(
-										"%q must be one of following values: (READ|WRITE|ALL). Please use uppercase values only", k))
-								}
-								return
-							},
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validatePrivilege,
 						},
 					},
 				},
@@ -64,85 +64,77 @@ func resourceUser() *schema.Resource {
 }
 
 func createUser(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 
 	name := d.Get("name").(string)
 	password := d.Get("password").(string)
 
-	is_admin := d.Get("admin").(bool)
-	admin_privileges := ""
-	if is_admin {
-		admin_privileges = "WITH ALL PRIVILEGES"
-	}
-
-	queryStr := fmt.Sprintf("CREATE USER %s WITH PASSWORD '%s' %s", quoteIdentifier(name), password, admin_privileges)
-	query := client.Query{
-		Command: queryStr,
-	}
-
-	resp, err := conn.Query(query)
-	if err != nil {
+	if err := createUserWithPassword(conn, name, password, d.Get("admin").(bool)); err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
 	d.SetId(fmt.Sprintf("influxdb-user:%s", name))
 
-	if v, ok := d.GetOk("grant"); ok {
-		grants := v.(*schema.Set).List()
-		for _, vv := range grants {
-			grant := vv.(map[string]interface{})
-			if err := grantPrivilegeOn(conn, grant["privilege"].(string), grant["database"].(string), name); err != nil {
-				return err
-			}
-		}
+	applied, err := updateGrants(conn, name, map[string]string{}, expandGrants(d.Get("grant").(*schema.Set)))
+	if err != nil {
+		d.Set("grant", flattenGrants(applied))
+		return err
 	}
 
 	return readUser(d, meta)
 }
 
-func grantPrivilegeOn(conn *client.Client, privilege, database, user string) error {
+func grantPrivilegeOn(conn *queryClient, privilege, database, user string) error {
 	return exec(conn, fmt.Sprintf("GRANT %s ON %s TO %s", privilege, quoteIdentifier(database), quoteIdentifier(user)))
 }
 
-func revokePrivilegeOn(conn *client.Client, privilege, database, user string) error {
+func revokePrivilegeOn(conn *queryClient, privilege, database, user string) error {
 	return exec(conn, fmt.Sprintf("REVOKE %s ON %s FROM %s", privilege, quoteIdentifier(database), quoteIdentifier(user)))
 }
 
-func grantAllOn(conn *client.Client, user string) error {
+func createUserWithPassword(conn *queryClient, user, password string, admin bool) error {
+	admin_privileges := ""
+	if admin {
+		admin_privileges = "WITH ALL PRIVILEGES"
+	}
+
+	return execWithPassword(conn, func(password string) string {
+		return fmt.Sprintf("CREATE USER %s WITH PASSWORD %s %s", quoteIdentifier(user), password, admin_privileges)
+	}, password)
+}
+
+func setUserPassword(conn *queryClient, user, password string) error {
+	return execWithPassword(conn, func(password string) string {
+		return fmt.Sprintf("SET PASSWORD FOR %s = %s", quoteIdentifier(user), password)
+	}, password)
+}
+
+func grantAllOn(conn *queryClient, user string) error {
 	return exec(conn, fmt.Sprintf("GRANT ALL PRIVILEGES TO %s", quoteIdentifier(user)))
 }
 
-func revokeAllOn(conn *client.Client, user string) error {
+func revokeAllOn(conn *queryClient, user string) error {
 	return exec(conn, fmt.Sprintf("REVOKE ALL PRIVILEGES FROM %s", quoteIdentifier(user)))
 }
 
 func readUser(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
-	name := d.Get("name").(string)
+	conn := meta.(*providerMeta).conn
+	name := strings.TrimPrefix(d.Id(), "influxdb-user:")
 
 	// InfluxDB doesn't have a command to check the existence of a single
 	// User, so we instead must read the list of all Users and see
 	// if ours is present in it.
-	query := client.Query{
-		Command: "SHOW USERS",
-	}
-
-	resp, err := conn.Query(query)
+	users, err := listUsers(conn)
 	if err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
 	var found = false
-	for _, result := range resp.Results[0].Series[0].Values {
-		if result[0] == name {
+	for _, user := range users {
+		if user.name == name {
 			found = true
-			d.Set("admin", result[1].(bool))
+			d.Set("name", name)
+			d.Set("admin", user.admin)
 			break
 		}
 	}
@@ -157,112 +149,240 @@ func readUser(d *schema.ResourceData, meta interface{}) error {
 	return readGrants(d, meta)
 }
 
-func readGrants(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
-	name := d.Get("name").(string)
+// influxUser is a single row of SHOW USERS output.
+type influxUser struct {
+	name  string
+	admin bool
+}
 
-	query := client.Query{
-		Command: fmt.Sprintf("SHOW GRANTS FOR %s", quoteIdentifier(name)),
+func listUsers(conn *queryClient) ([]influxUser, error) {
+	rows, err := showRows(conn, "SHOW USERS")
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := conn.Query(query)
-	if err != nil {
-		return err
+	var users []influxUser
+	for _, row := range rows {
+		var user influxUser
+		if user.name, err = row.string("user"); err != nil {
+			return nil, err
+		}
+		if user.admin, err = row.bool("admin"); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
 	}
 
-	if resp.Err != nil {
-		return resp.Err
+	return users, nil
+}
+
+func readGrants(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*providerMeta).conn
+	name := d.Get("name").(string)
+
+	privileges, err := listGrants(conn, name)
+	if err != nil {
+		return err
 	}
 
+	// Only refresh the grants we already track, so that grants managed by
+	// influxdb_grant resources are left alone.
 	var grants = []map[string]string{}
-	for _, result := range resp.Results[0].Series[0].Values {
-		if result[1].(string) != "NO PRIVILEGES" {
-			var grant = map[string]string{
-				"database":  result[0].(string),
-				"privilege": strings.Replace(strings.ToUpper(result[1].(string)), "ALL PRIVILEGES", "ALL", 1),
-			}
-			grants = append(grants, grant)
+	for _, v := range d.Get("grant").(*schema.Set).List() {
+		database := v.(map[string]interface{})["database"].(string)
+		if privilege, ok := privileges[database]; ok {
+			grants = append(grants, map[string]string{
+				"database":  database,
+				"privilege": privilege,
+			})
 		}
 	}
 	d.Set("grant", grants)
 	return nil
 }
 
+// listGrants returns the privileges of a user, keyed by database.
+func listGrants(conn *queryClient, user string) (map[string]string, error) {
+	rows, err := showRows(conn, fmt.Sprintf("SHOW GRANTS FOR %s", quoteIdentifier(user)))
+	if err != nil {
+		return nil, err
+	}
+
+	var privileges = map[string]string{}
+	for _, row := range rows {
+		database, err := row.string("database")
+		if err != nil {
+			return nil, err
+		}
+		privilege, err := row.string("privilege")
+		if err != nil {
+			return nil, err
+		}
+		if privilege != "NO PRIVILEGES" {
+			privileges[database] = flattenPrivilege(privilege)
+		}
+	}
+	return privileges, nil
+}
+
+// flattenGrants converts privileges keyed by database into a list of grants
+// sorted by database.
+func flattenGrants(privileges map[string]string) []map[string]string {
+	databases := make([]string, 0, len(privileges))
+	for database := range privileges {
+		databases = append(databases, database)
+	}
+	sort.Strings(databases)
+
+	var grants = []map[string]string{}
+	for _, database := range databases {
+		grants = append(grants, map[string]string{
+			"database":  database,
+			"privilege": privileges[database],
+		})
+	}
+	return grants
+}
+
+// flattenPrivilege converts a privilege as reported by SHOW GRANTS into the
+// form used in configuration.
+func flattenPrivilege(privilege string) string {
+	return strings.Replace(strings.ToUpper(privilege), "ALL PRIVILEGES", "ALL", 1)
+}
+
+func validatePrivilege(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "READ", "WRITE", "ALL":
+	default:
+		errors = append(errors, fmt.Errorf(
+			"%q must be one of following values: (READ|WRITE|ALL). Please use uppercase values only", k))
+	}
+	return
+}
+
+// importUser adopts every grant of the user.
+func importUser(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	conn := meta.(*providerMeta).conn
+
+	privileges, err := listGrants(conn, strings.TrimPrefix(d.Id(), "influxdb-user:"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.Set("grant", flattenGrants(privileges)); err != nil {
+		return nil, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
 func updateUser(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 	name := d.Get("name").(string)
 
+	// Only record the password and admin changes the server accepted if one
+	// of the statements fails.
+	d.Partial(true)
+
+	// The password of an imported user can't be read back from the server,
+	// so the first update after an import sets it too.
+	if d.HasChange("password") || d.HasChange("password_version") {
+		if err := setUserPassword(conn, name, d.Get("password").(string)); err != nil {
+			return fmt.Errorf("error setting the password of %s: %s", name, err)
+		}
+		d.SetPartial("password")
+		d.SetPartial("password_version")
+	}
+
 	if d.HasChange("admin") {
 		if !d.Get("admin").(bool) {
-			revokeAllOn(conn, name)
+			if err := revokeAllOn(conn, name); err != nil {
+				return fmt.Errorf("error revoking admin privileges from %s: %s", name, err)
+			}
 		} else {
-			grantAllOn(conn, name)
+			if err := grantAllOn(conn, name); err != nil {
+				return fmt.Errorf("error granting admin privileges to %s: %s", name, err)
+			}
 		}
+		d.SetPartial("admin")
 	}
 
 	if d.HasChange("grant") {
-		oldGrantV, newGrantV := d.GetChange("grant")
-		oldGrant := oldGrantV.(*schema.Set).List()
-		newGrant := newGrantV.(*schema.Set).List()
-
-		for _, oGV := range oldGrant {
-			oldGrant := oGV.(map[string]interface{})
-
-			exists := false
-			privilege := oldGrant["privilege"].(string)
-			for _, nGV := range newGrant {
-				newGrant := nGV.(map[string]interface{})
-
-				if newGrant["database"].(string) == oldGrant["database"].(string) {
-					exists = true
-					privilege = newGrant["privilege"].(string)
-				}
-			}
-
-			if !exists {
-				revokePrivilegeOn(conn, oldGrant["privilege"].(string), oldGrant["database"].(string), name)
-			} else {
-				if privilege != oldGrant["privilege"].(string) {
-					grantPrivilegeOn(conn, privilege, oldGrant["database"].(string), name)
-				}
-			}
+		oldGrants, newGrants := d.GetChange("grant")
+		applied, err := updateGrants(conn, name, expandGrants(oldGrants.(*schema.Set)), expandGrants(newGrants.(*schema.Set)))
+		// Record the grants applied before any failure, as the next refresh
+		// only looks at the databases the state already has grants on.
+		d.Set("grant", flattenGrants(applied))
+		d.SetPartial("grant")
+		if err != nil {
+			return err
 		}
+	}
 
-		for _, nGV := range newGrant {
-			newGrant := nGV.(map[string]interface{})
+	d.Partial(false)
 
-			exists := false
-			for _, oGV := range oldGrant {
-				oldGrant := oGV.(map[string]interface{})
+	return readUser(d, meta)
+}
 
-				exists = exists || (newGrant["database"].(string) == oldGrant["database"].(string) && newGrant["privilege"].(string) == oldGrant["privilege"].(string))
-			}
+// updateGrants revokes and grants privileges so that the user goes from the
+// old to the new privileges, keyed by database. Databases are handled in
+// order, stopping at the first statement that fails, and the privileges the
+// user is left with are returned.
+func updateGrants(conn *queryClient, user string, old, new map[string]string) (map[string]string, error) {
+	applied := make(map[string]string, len(old))
+	for database, privilege := range old {
+		applied[database] = privilege
+	}
 
-			if !exists {
-				grantPrivilegeOn(conn, newGrant["privilege"].(string), newGrant["database"].(string), name)
+	databases := make([]string, 0, len(old)+len(new))
+	for database := range old {
+		databases = append(databases, database)
+	}
+	for database := range new {
+		if _, ok := old[database]; !ok {
+			databases = append(databases, database)
+		}
+	}
+	sort.Strings(databases)
+
+	for _, database := range databases {
+		oldPrivilege, hadPrivilege := old[database]
+		newPrivilege, hasPrivilege := new[database]
+
+		switch {
+		case !hasPrivilege:
+			if err := revokePrivilegeOn(conn, oldPrivilege, database, user); err != nil {
+				return applied, fmt.Errorf("error revoking %s on %s from %s: %s", oldPrivilege, database, user, err)
+			}
+			delete(applied, database)
+		case !hadPrivilege || newPrivilege != oldPrivilege:
+			if err := grantPrivilegeOn(conn, newPrivilege, database, user); err != nil {
+				return applied, fmt.Errorf("error granting %s on %s to %s: %s", newPrivilege, database, user, err)
 			}
+			applied[database] = newPrivilege
 		}
 	}
+	return applied, nil
+}
 
-	return readUser(d, meta)
+// expandGrants converts a set of grants into privileges keyed by database.
+func expandGrants(grants *schema.Set) map[string]string {
+	privileges := make(map[string]string)
+	for _, v := range grants.List() {
+		grant := v.(map[string]interface{})
+		privileges[grant["database"].(string)] = grant["privilege"].(string)
+	}
+	return privileges
 }
 
 func deleteUser(d *schema.ResourceData, meta interface{}) error {
-	conn := meta.(*client.Client)
+	conn := meta.(*providerMeta).conn
 	name := d.Get("name").(string)
 
-	queryStr := fmt.Sprintf("DROP USER %s", quoteIdentifier(name))
-	query := client.Query{
-		Command: queryStr,
-	}
-
-	resp, err := conn.Query(query)
-	if err != nil {
+	if err := exec(conn, fmt.Sprintf("DROP USER %s", quoteIdentifier(name))); err != nil {
 		return err
 	}
-	if resp.Err != nil {
-		return resp.Err
-	}
 
 	d.SetId("")
 
@@ -2,9 +2,13 @@ package influxdb
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/influxdata/influxdb/client"
 )
@@ -91,6 +95,12 @@ func TestAccInfluxDBUser_grant(t *testing.T) {
 					),
 				),
 			},
+			{
+				ResourceName:            "influxdb_user.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
 		},
 	})
 }
@@ -140,6 +150,136 @@ func TestAccInfluxDBUser_revoke(t *testing.T) {
 	})
 }
 
+func TestAccInfluxDBUser_password(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccUserConfig_password("terraform", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserPassword("influxdb_user.test", "terraform"),
+					testAccCheckUserGrants("influxdb_user.test", "terraform-green", "READ"),
+				),
+			},
+			{
+				Config: testAccUserConfig_password("terraform-rotated", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserPassword("influxdb_user.test", "terraform-rotated"),
+					testAccCheckUserGrants("influxdb_user.test", "terraform-green", "READ"),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "password", hashSum("terraform-rotated"),
+					),
+				),
+			},
+			{
+				// Bumping the version sets the password again, restoring
+				// it after it was changed outside of Terraform.
+				PreConfig: func() {
+					conn := testAccProvider.Meta().(*providerMeta).conn
+					if err := setUserPassword(conn, "terraform_test", "changed"); err != nil {
+						t.Fatalf("err: %s", err)
+					}
+				},
+				Config: testAccUserConfig_password("terraform-rotated", "2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserPassword("influxdb_user.test", "terraform-rotated"),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "password_version", "2",
+					),
+				),
+			},
+		},
+	})
+}
+
+func TestInfluxDBUser(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckFakeUserDestroyed(fake, "terraform_test"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccUserConfig_admin,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "terraform_test", true),
+					testCheckFakeUserPassword(fake, "terraform_test", "terraform"),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "password", hashSum("terraform"),
+					),
+				),
+			},
+			{
+				PreConfig:          func() { fake.run(t, `REVOKE ALL PRIVILEGES FROM terraform_test`) },
+				Config:             fake.providerConfig() + testAccUserConfig_admin,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_admin,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_test", true),
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_revoke,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_test", false),
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_grant,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_test", false, "terraform-green", "READ"),
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_grantUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "terraform_test", false, "terraform-red", "ALL", "terraform-green", "WRITE", "terraform-blue", "READ"),
+					testAccCheckUserGrants("influxdb_user.test", "terraform-red", "ALL PRIVILEGES"),
+					resource.TestCheckResourceAttr(
+						"influxdb_user.test", "grant.#", "3",
+					),
+				),
+			},
+			{
+				Config:                  fake.providerConfig() + testAccUserConfig_grantUpdate,
+				ResourceName:            "influxdb_user.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+			{
+				PreConfig: func() {
+					fake.run(t, `REVOKE WRITE ON "terraform-green" FROM terraform_test`)
+				},
+				Config:             fake.providerConfig() + testAccUserConfig_grantUpdate,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_grantUpdate,
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_test", false, "terraform-red", "ALL", "terraform-green", "WRITE", "terraform-blue", "READ"),
+			},
+			{
+				// Passwords can't be read back, so changing one isn't drift.
+				PreConfig: func() { fake.run(t, `SET PASSWORD FOR terraform_test = 'changed'`) },
+				Config:    fake.providerConfig() + testAccUserConfig_grantUpdate,
+				PlanOnly:  true,
+			},
+			{
+				PreConfig:          func() { fake.run(t, `DROP USER terraform_test`) },
+				Config:             fake.providerConfig() + testAccUserConfig_grantUpdate,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: fake.providerConfig() + testAccUserConfig_grantUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "terraform_test", false, "terraform-red", "ALL", "terraform-green", "WRITE", "terraform-blue", "READ"),
+					testCheckFakeUserPassword(fake, "terraform_test", "terraform"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckUserExists(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -151,23 +291,15 @@ func testAccCheckUserExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: "SHOW USERS",
-		}
-
-		resp, err := conn.Query(query)
+		users, err := listUsers(conn)
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0] == rs.Primary.Attributes["name"] {
+		for _, user := range users {
+			if user.name == rs.Primary.Attributes["name"] {
 				return nil
 			}
 		}
@@ -176,6 +308,30 @@ func testAccCheckUserExists(n string) resource.TestCheckFunc {
 	}
 }
 
+// testAccCheckUserPassword checks that the user can authenticate with
+// password.
+func testAccCheckUserPassword(n, password string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		conn := testAccProvider.Meta().(*providerMeta).conn
+		auth := queryAuth{username: rs.Primary.Attributes["name"], password: password}
+		userConn := newQueryClient(conn.nodes, auth, conn.httpClient, conn.retry, false)
+
+		resp, err := userConn.Query(client.Query{Command: "SHOW DATABASES"})
+		if err != nil {
+			return err
+		}
+		if err := resp.Error(); err != nil {
+			return fmt.Errorf("User %q can't authenticate with its password: %s", auth.username, err)
+		}
+		return nil
+	}
+}
+
 func testAccCheckUserNoAdmin(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -187,24 +343,16 @@ func testAccCheckUserNoAdmin(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: "SHOW USERS",
-		}
-
-		resp, err := conn.Query(query)
+		users, err := listUsers(conn)
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0] == rs.Primary.Attributes["name"] {
-				if result[1].(bool) == true {
+		for _, user := range users {
+			if user.name == rs.Primary.Attributes["name"] {
+				if user.admin {
 					return fmt.Errorf("User %q is admin", rs.Primary.ID)
 				}
 
@@ -227,25 +375,15 @@ func testAccCheckUserGrantsEmpty(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
-
-		query := client.Query{
-			Command: fmt.Sprintf("SHOW GRANTS FOR %s", rs.Primary.Attributes["name"]),
-		}
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		resp, err := conn.Query(query)
+		privileges, err := listGrants(conn, rs.Primary.Attributes["name"])
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[1].(string) != "NO PRIVILEGES" {
-				return fmt.Errorf("User %q still has grants: %#v", rs.Primary.ID, resp.Results[0].Series[0].Values)
-			}
+		if len(privileges) > 0 {
+			return fmt.Errorf("User %q still has grants: %#v", rs.Primary.ID, privileges)
 		}
 
 		return nil
@@ -263,23 +401,25 @@ func testAccCheckUserGrants(n, database, privilege string) resource.TestCheckFun
 			return fmt.Errorf("No user id set")
 		}
 
-		conn := testAccProvider.Meta().(*client.Client)
+		conn := testAccProvider.Meta().(*providerMeta).conn
 
-		query := client.Query{
-			Command: fmt.Sprintf("SHOW GRANTS FOR %s", rs.Primary.Attributes["name"]),
-		}
-
-		resp, err := conn.Query(query)
+		// The privileges are compared as InfluxDB reports them, such as
+		// "ALL PRIVILEGES".
+		rows, err := showRows(conn, fmt.Sprintf("SHOW GRANTS FOR %s", quoteIdentifier(rs.Primary.Attributes["name"])))
 		if err != nil {
 			return err
 		}
 
-		if resp.Err != nil {
-			return resp.Err
-		}
-
-		for _, result := range resp.Results[0].Series[0].Values {
-			if result[0].(string) == database && result[1].(string) == privilege {
+		for _, row := range rows {
+			d, err := row.string("database")
+			if err != nil {
+				return err
+			}
+			p, err := row.string("privilege")
+			if err != nil {
+				return err
+			}
+			if d == database && p == privilege {
 				return nil
 			}
 		}
@@ -357,3 +497,235 @@ resource "influxdb_user" "test" {
     }
 }
 `
+
+func testAccUserConfig_password(password, version string) string {
+	return fmt.Sprintf(`
+resource "influxdb_database" "green" {
+    name = "terraform-green"
+}
+
+resource "influxdb_user" "test" {
+    name = "terraform_test"
+    password = %q
+    password_version = %q
+
+    grant {
+      database = "${influxdb_database.green.name}"
+      privilege = "READ"
+    }
+}
+`, password, version)
+}
+
+func TestInfluxDBUser_updateErrors(t *testing.T) {
+	fake := newFakeInfluxQL("blue", "green", "red")
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testUserGrantsConfig(false, "blue", "READ", "green", "READ"),
+				Check:  testCheckFakeUserPrivileges(fake, "terraform_test", false, "blue", "READ", "green", "READ"),
+			},
+			{
+				// blue and green are updated before red is refused.
+				PreConfig: func() { fake.deny(`^GRANT ALL ON red TO`) },
+				Config:    fake.providerConfig() + testUserGrantsConfig(false, "green", "WRITE", "red", "ALL"),
+				ExpectError: regexp.MustCompile(
+					`error granting ALL on red to terraform_test: error authorizing query: terraform not authorized to execute statement 'GRANT ALL ON red TO terraform_test', requires admin privilege`,
+				),
+			},
+			{
+				// The refresh drops the refused grant from the state.
+				Config:   fake.providerConfig() + testUserGrantsConfig(false, "green", "WRITE"),
+				PlanOnly: true,
+			},
+			{
+				// blue is granted before red is refused again.
+				Config:      fake.providerConfig() + testUserGrantsConfig(false, "blue", "READ", "green", "WRITE", "red", "ALL"),
+				ExpectError: regexp.MustCompile(`error granting ALL on red to terraform_test`),
+			},
+			{
+				// blue was recorded along with green.
+				Config:   fake.providerConfig() + testUserGrantsConfig(false, "blue", "READ", "green", "WRITE"),
+				PlanOnly: true,
+			},
+			{
+				// blue was recorded, so it is revoked once it is removed.
+				Config: fake.providerConfig() + testUserGrantsConfig(false, "green", "WRITE"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckFakeUserPrivileges(fake, "terraform_test", false, "green", "WRITE"),
+					resource.TestCheckResourceAttr("influxdb_user.test", "grant.#", "1"),
+				),
+			},
+			{
+				PreConfig: func() {
+					fake.allow()
+					fake.deny(`^GRANT ALL PRIVILEGES TO`)
+				},
+				Config:      fake.providerConfig() + testUserGrantsConfig(true, "green", "WRITE", "red", "ALL"),
+				ExpectError: regexp.MustCompile(`error granting admin privileges to terraform_test: error authorizing query`),
+			},
+			{
+				// admin wasn't recorded as granted.
+				Config:             fake.providerConfig() + testUserGrantsConfig(true, "green", "WRITE", "red", "ALL"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig() + testUserGrantsConfig(true, "green", "WRITE", "red", "ALL"),
+				Check:     testCheckFakeUserPrivileges(fake, "terraform_test", true, "green", "WRITE", "red", "ALL"),
+			},
+			{
+				// Errors of a statement are reported too.
+				PreConfig:   func() { fake.fail(`^REVOKE ALL ON red FROM`, "timeout") },
+				Config:      fake.providerConfig() + testUserGrantsConfig(true, "green", "WRITE"),
+				ExpectError: regexp.MustCompile(`error revoking ALL on red from terraform_test: timeout`),
+			},
+		},
+	})
+}
+
+func TestInfluxDBUser_deleteErrors(t *testing.T) {
+	fake := newFakeInfluxQL()
+	defer fake.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fake.providerConfig() + testAccUserConfig_admin,
+			},
+			{
+				PreConfig:   func() { fake.fail(`^DROP USER`, "timeout") },
+				Config:      fake.providerConfig(),
+				ExpectError: regexp.MustCompile(`timeout`),
+			},
+			{
+				// The user is still in the state, so it is dropped again.
+				PreConfig: fake.allow,
+				Config:    fake.providerConfig(),
+				Check:     testCheckFakeUserDestroyed(fake, "terraform_test"),
+			},
+		},
+	})
+}
+
+// TestUpdateUser_partialGrants checks that the grants applied before one is
+// refused are recorded in the state, as the next refresh only looks at the
+// databases it already has grants on.
+func TestUpdateUser_partialGrants(t *testing.T) {
+	fake := newFakeInfluxQL("blue", "red")
+	defer fake.Close()
+	fake.run(t, `CREATE USER terraform_test WITH PASSWORD 'terraform'`)
+	fake.deny(`^GRANT READ ON red TO`)
+
+	meta, err := testConfigure(t, map[string]interface{}{
+		"url":         fake.URL,
+		"username":    testQueryUser,
+		"password":    testQueryPassword,
+		"max_retries": 0,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceUser().Schema, map[string]interface{}{
+		"name":     "terraform_test",
+		"password": "terraform",
+		"grant": []interface{}{
+			map[string]interface{}{"database": "blue", "privilege": "READ"},
+			map[string]interface{}{"database": "red", "privilege": "READ"},
+		},
+	})
+	d.SetId("influxdb-user:terraform_test")
+
+	if err := updateUser(d, meta); err == nil {
+		t.Fatal("expected an error granting READ on red")
+	}
+
+	var databases []string
+	state := d.State()
+	for k, v := range state.Attributes {
+		if strings.HasPrefix(k, "grant.") && strings.HasSuffix(k, ".database") {
+			databases = append(databases, v)
+		}
+	}
+	if !reflect.DeepEqual(databases, []string{"blue"}) {
+		t.Fatalf("expected the grant on blue to be recorded, got %v", state.Attributes)
+	}
+}
+
+// testCheckFakeUserPrivileges checks the privileges of a user of the fake
+// server, given as database and privilege pairs.
+func testCheckFakeUserPrivileges(fake *fakeInfluxQL, name string, admin bool, privileges ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		u := fake.user(name)
+		if u == nil {
+			return fmt.Errorf("User %q does not exist", name)
+		}
+		if u.admin != admin {
+			return fmt.Errorf("expected admin to be %t, got %t", admin, u.admin)
+		}
+
+		expected := map[string]string{}
+		for i := 0; i < len(privileges); i += 2 {
+			expected[privileges[i]] = privileges[i+1]
+		}
+		if grants := u.grants(); !reflect.DeepEqual(grants, expected) {
+			return fmt.Errorf("expected privileges %v, got %v", expected, grants)
+		}
+		return nil
+	}
+}
+
+// testCheckFakeUserPassword checks the password of a user of the fake server.
+func testCheckFakeUserPassword(fake *fakeInfluxQL, name, password string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		u := fake.user(name)
+		if u == nil {
+			return fmt.Errorf("User %q does not exist", name)
+		}
+		if u.password != password {
+			return fmt.Errorf("expected the password of %q to be %q, got %q", name, password, u.password)
+		}
+		return nil
+	}
+}
+
+// testCheckFakeUserDestroyed checks that the fake server no longer has the
+// given users.
+func testCheckFakeUserDestroyed(fake *fakeInfluxQL, names ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, name := range names {
+			if fake.user(name) != nil {
+				return fmt.Errorf("User %q still exists", name)
+			}
+		}
+		return nil
+	}
+}
+
+// testUserGrantsConfig returns the configuration of a user with grants given
+// as database and privilege pairs.
+func testUserGrantsConfig(admin bool, grants ...string) string {
+	var b strings.Builder
+	for i := 0; i < len(grants); i += 2 {
+		fmt.Fprintf(&b, `
+    grant {
+      database = %q
+      privilege = %q
+    }
+`, grants[i], grants[i+1])
+	}
+
+	return fmt.Sprintf(`
+resource "influxdb_user" "test" {
+    name = "terraform_test"
+    password = "terraform"
+    admin = %t
+%s}
+`, admin, b.String())
+}
@@ -0,0 +1,140 @@
+package influxdb
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// v2User is an InfluxDB 2.x user as returned by /api/v2/users. Unlike 1.x
+// users, its privileges come from organization membership and API tokens.
+type v2User struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+}
+
+func resourceV2User() *schema.Resource {
+	return &schema.Resource{
+		Create: createV2User,
+		Read:   readV2User,
+		Update: updateV2User,
+		Delete: deleteV2User,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"password": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+				StateFunc: hashSum,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "active",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+					switch value {
+					case "active", "inactive":
+					default:
+						errors = append(errors, fmt.Errorf(
+							"%q must be one of following values: (active|inactive)", k))
+					}
+					return
+				},
+			},
+		},
+	}
+}
+
+func createV2User(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	user := v2User{
+		Name:   d.Get("name").(string),
+		Status: d.Get("status").(string),
+	}
+	if err := api.do("POST", "/users", nil, &user, &user); err != nil {
+		return err
+	}
+
+	d.SetId(user.ID)
+
+	if password := d.Get("password").(string); password != "" {
+		if err := setV2UserPassword(api, user.ID, password); err != nil {
+			return err
+		}
+	}
+
+	return readV2User(d, meta)
+}
+
+func readV2User(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	var user v2User
+	if err := api.do("GET", "/users/"+d.Id(), nil, nil, &user); err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", user.Name)
+	d.Set("status", user.Status)
+
+	return nil
+}
+
+func updateV2User(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if d.HasChange("name") || d.HasChange("status") {
+		user := v2User{
+			Name:   d.Get("name").(string),
+			Status: d.Get("status").(string),
+		}
+		if err := api.do("PATCH", "/users/"+d.Id(), nil, &user, nil); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("password") {
+		if err := setV2UserPassword(api, d.Id(), d.Get("password").(string)); err != nil {
+			return err
+		}
+	}
+
+	return readV2User(d, meta)
+}
+
+func deleteV2User(d *schema.ResourceData, meta interface{}) error {
+	api := meta.(*providerMeta).api
+
+	if err := api.do("DELETE", "/users/"+d.Id(), nil, nil, nil); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// setV2UserPassword sets the password of a user through the password
+// endpoint, as passwords can't be part of the user object.
+func setV2UserPassword(api *apiClient, id, password string) error {
+	if password == "" {
+		return fmt.Errorf("the password of user %q can't be removed, only changed", id)
+	}
+
+	body := map[string]string{"password": password}
+	return api.do("POST", "/users/"+id+"/password", nil, body, nil)
+}
@@ -0,0 +1,73 @@
+package influxdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestInfluxDBV2User(t *testing.T) {
+	api := newFakeAPI()
+	defer api.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAPIObjectDestroyed(api, "users", "influxdb_v2_user"),
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + testV2UserConfig("active", "super-secret"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "users", "influxdb_v2_user.test", "name", "terraform-test"),
+					testCheckV2UserPassword(api, "influxdb_v2_user.test", "super-secret"),
+					resource.TestCheckResourceAttr(
+						"influxdb_v2_user.test", "password", hashSum("super-secret"),
+					),
+					resource.TestCheckResourceAttr(
+						"influxdb_v2_user.test", "status", "active",
+					),
+				),
+			},
+			{
+				Config: api.providerConfig() + testV2UserConfig("inactive", "even-more-secret"),
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAPIObject(api, "users", "influxdb_v2_user.test", "status", "inactive"),
+					testCheckV2UserPassword(api, "influxdb_v2_user.test", "even-more-secret"),
+				),
+			},
+			{
+				Config:                  api.providerConfig() + testV2UserConfig("inactive", "even-more-secret"),
+				ResourceName:            "influxdb_v2_user.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+		},
+	})
+}
+
+func testCheckV2UserPassword(api *fakeAPI, n, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if password := api.password(rs.Primary.ID); password != expected {
+			return fmt.Errorf("expected password %q, got %q", expected, password)
+		}
+
+		return nil
+	}
+}
+
+func testV2UserConfig(status, password string) string {
+	return fmt.Sprintf(`
+resource "influxdb_v2_user" "test" {
+  name     = "terraform-test"
+  status   = "%s"
+  password = "%s"
+}
+`, status, password)
+}
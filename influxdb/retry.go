@@ -0,0 +1,185 @@
+package influxdb
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// retryPolicy describes how often and how long to wait before repeating a
+// query that failed because the server was unavailable.
+type retryPolicy struct {
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+}
+
+// wait returns the delay before the given retry, doubling from waitMin up
+// to waitMax.
+func (p retryPolicy) wait(retry int) time.Duration {
+	wait := p.waitMin
+	for i := 0; i < retry && wait < p.waitMax; i++ {
+		wait *= 2
+	}
+	if wait > p.waitMax {
+		wait = p.waitMax
+	}
+	return wait
+}
+
+// run calls f until it succeeds, fails with an error that isn't transient,
+// or has been retried the given number of times, and returns its last error.
+func (p retryPolicy) run(retries int, f func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= retries || !isTransientError(err) {
+			return err
+		}
+
+		// Statements aren't logged as they may hold passwords.
+		wait := p.wait(attempt)
+		log.Printf("[WARN] Request failed (attempt %d of %d), retrying in %s: %s", attempt+1, retries+1, wait, err)
+		time.Sleep(wait)
+	}
+}
+
+// retryableStatements lists the statements that can be sent again when it is
+// unknown whether the server ran them, because running them twice has the
+// same effect as running them once. Statements such as DROP USER fail when
+// repeated and are left out.
+var retryableStatements = []string{
+	"SHOW",
+	"CREATE DATABASE",
+	"DROP DATABASE",
+	"CREATE RETENTION POLICY",
+	"ALTER RETENTION POLICY",
+	"DROP RETENTION POLICY",
+	// CREATE USER succeeds when the user exists with the same password
+	// and admin privilege.
+	"CREATE USER",
+	"SET PASSWORD",
+	"GRANT",
+	"REVOKE",
+	// CREATE CONTINUOUS QUERY succeeds when the query exists with the same
+	// definition.
+	"CREATE CONTINUOUS QUERY",
+}
+
+// isRetryableCommand reports whether every statement of an InfluxQL command
+// can safely be repeated.
+func isRetryableCommand(command string) bool {
+	return everyStatement(command, isRetryableStatement)
+}
+
+// isReadOnlyCommand reports whether an InfluxQL command is only made of
+// statements that don't change the server.
+func isReadOnlyCommand(command string) bool {
+	return everyStatement(command, func(words []string) bool {
+		return words[0] == "SHOW" || isRetryableStatement(words) && words[0] == "SELECT"
+	})
+}
+
+// isShowCommand reports whether an InfluxQL command is only made of SHOW
+// statements.
+func isShowCommand(command string) bool {
+	return everyStatement(command, func(words []string) bool {
+		return words[0] == "SHOW"
+	})
+}
+
+// everyStatement reports whether a command has statements, and f returns
+// true for the upper-cased words of each of them.
+func everyStatement(command string, f func(words []string) bool) bool {
+	var statements [][]string
+	var words []string
+	for _, token := range tokenizeQuery(command) {
+		if token.text == ";" {
+			statements = append(statements, words)
+			words = nil
+			continue
+		}
+		words = append(words, strings.ToUpper(token.text))
+	}
+	statements = append(statements, words)
+
+	ok := false
+	for _, words := range statements {
+		if len(words) == 0 {
+			continue
+		}
+		if !f(words) {
+			return false
+		}
+		ok = true
+	}
+	return ok
+}
+
+func isRetryableStatement(words []string) bool {
+	if words[0] == "SELECT" {
+		// SELECT ... INTO writes points.
+		for _, word := range words {
+			if word == "INTO" {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, statement := range retryableStatements {
+		prefix := strings.Fields(statement)
+		if len(words) >= len(prefix) && strings.Join(words[:len(prefix)], " ") == statement {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientError reports whether err is a failure to reach the server or
+// an unavailable server, rather than an error the server reported for a
+// statement.
+func isTransientError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	switch err := err.(type) {
+	case net.Error, *transientStatusError:
+		return true
+	case nodeErrors:
+		for _, e := range err {
+			if !isTransientError(e.err) {
+				return false
+			}
+		}
+		return true
+	}
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// transientStatusError is returned for the status codes of a server that is
+// restarting, or of a proxy in front of an unavailable server.
+type transientStatusError struct {
+	statusCode int
+	// err is the error reported by the server in the response, if any.
+	err error
+}
+
+func (e *transientStatusError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%s (status code %d)", e.err, e.statusCode)
+	}
+	return fmt.Sprintf("received status code %d from server", e.statusCode)
+}
+
+func isTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
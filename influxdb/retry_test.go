@@ -0,0 +1,164 @@
+package influxdb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+func TestIsRetryableCommand(t *testing.T) {
+	retryable := []string{
+		"SHOW DATABASES",
+		"show retention policies on \"telegraf\"",
+		"CREATE DATABASE \"telegraf\"",
+		"CREATE RETENTION POLICY \"1w\" ON \"telegraf\" DURATION 1w REPLICATION 1",
+		"ALTER RETENTION POLICY \"1w\" ON \"telegraf\" DEFAULT",
+		"DROP RETENTION POLICY \"1w\" ON \"telegraf\"",
+		"DROP DATABASE \"telegraf\"",
+		"CREATE USER \"paul\" WITH PASSWORD 'a;b' WITH ALL PRIVILEGES",
+		"SET PASSWORD FOR \"paul\" = 'secret'",
+		"GRANT READ ON \"telegraf\" TO \"paul\"",
+		"REVOKE ALL PRIVILEGES FROM \"paul\"",
+		"CREATE CONTINUOUS QUERY \"cq\" ON \"telegraf\" BEGIN SELECT mean(v) INTO m FROM n GROUP BY time(1h) END",
+		"SELECT mean(value) FROM cpu",
+		"GRANT ALL ON \"a\" TO \"paul\"; GRANT READ ON \"b\" TO \"paul\";",
+	}
+	for _, command := range retryable {
+		if !isRetryableCommand(command) {
+			t.Errorf("expected %q to be retryable", command)
+		}
+	}
+
+	notRetryable := []string{
+		"",
+		"DROP USER \"paul\"",
+		"DROP CONTINUOUS QUERY \"cq\" ON \"telegraf\"",
+		"DROP CONTINUOUS QUERY \"cq\" ON \"telegraf\"; CREATE CONTINUOUS QUERY \"cq\" ON \"telegraf\" BEGIN SELECT mean(v) INTO m FROM n GROUP BY time(1h) END",
+		"SELECT mean(value) INTO cpu_1h FROM cpu",
+		"DELETE FROM cpu",
+		"KILL QUERY 12",
+		"SHOW DATABASES; DROP SERIES FROM cpu",
+		"CREATE SUBSCRIPTION \"s\" ON \"telegraf\".\"autogen\" DESTINATIONS ALL 'udp://h:9090'",
+	}
+	for _, command := range notRetryable {
+		if isRetryableCommand(command) {
+			t.Errorf("expected %q not to be retryable", command)
+		}
+	}
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	p := retryPolicy{maxRetries: 5, waitMin: time.Second, waitMax: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for retry, wait := range expected {
+		if actual := p.wait(retry); actual != wait {
+			t.Errorf("expected retry %d to wait %s, got %s", retry, wait, actual)
+		}
+	}
+}
+
+// flakyServer answers /ping, and /query with the given status codes, then with an empty
+// successful result, and records the commands it received.
+type flakyServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures []int
+	commands []string
+}
+
+func newFlakyServer(failures ...int) *flakyServer {
+	s := &flakyServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.commands = append(s.commands, r.FormValue("q"))
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			if status == 0 {
+				// Drop the connection, as a restarting server does.
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"meta service unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	return s
+}
+
+func (s *flakyServer) client(maxRetries int) *queryClient {
+	u, _ := url.Parse(s.URL)
+	return newQueryClient([]url.URL{*u}, queryAuth{}, http.DefaultClient, retryPolicy{
+		maxRetries: maxRetries,
+		waitMin:    time.Millisecond,
+		waitMax:    5 * time.Millisecond,
+	}, false)
+}
+
+func TestQueryClient_retry(t *testing.T) {
+	srv := newFlakyServer(http.StatusServiceUnavailable, 0, http.StatusBadGateway)
+	defer srv.Close()
+
+	if err := exec(srv.client(3), "CREATE DATABASE \"telegraf\""); err != nil {
+		t.Fatalf("expected the statement to succeed after retrying, got %s", err)
+	}
+	if len(srv.commands) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(srv.commands))
+	}
+}
+
+func TestQueryClient_retryExhausted(t *testing.T) {
+	srv := newFlakyServer(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer srv.Close()
+
+	_, err := srv.client(2).Query(client.Query{Command: "SHOW DATABASES"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "meta service unavailable (status code 503)") {
+		t.Fatalf("expected the last error of the server, got %q", err)
+	}
+	if len(srv.commands) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(srv.commands))
+	}
+}
+
+func TestQueryClient_noRetry(t *testing.T) {
+	// DROP USER fails when repeated after succeeding, so it isn't retried.
+	srv := newFlakyServer(0)
+	defer srv.Close()
+
+	if err := exec(srv.client(3), "DROP USER \"paul\""); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if len(srv.commands) != 1 {
+		t.Fatalf("expected a single attempt, got %d", len(srv.commands))
+	}
+
+	// Errors reported for statements aren't retried either.
+	srv = newFlakyServer(http.StatusBadRequest)
+	defer srv.Close()
+
+	if err := exec(srv.client(3), "SHOW DATABASES"); err == nil || !strings.Contains(err.Error(), "meta service unavailable") {
+		t.Fatalf("expected the error of the server, got %v", err)
+	}
+	if len(srv.commands) != 1 {
+		t.Fatalf("expected a single attempt, got %d", len(srv.commands))
+	}
+}
@@ -0,0 +1,136 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// showSeries is a series of the output of a SHOW statement.
+type showSeries struct {
+	name string
+	rows []showRow
+}
+
+// showRow is a row of the output of a SHOW statement. Its values are looked
+// up by column name, as the columns InfluxDB returns and their order vary
+// across versions.
+type showRow struct {
+	command string
+	values  map[string]interface{}
+}
+
+// show runs a SHOW statement and decodes its output. Statements with nothing
+// to report, such as SHOW USERS on a server without users, may come back
+// without any series, which yields no series rather than an error.
+func show(conn *queryClient, command string) ([]showSeries, error) {
+	resp, err := conn.Query(client.Query{
+		Command: command,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+
+	return decodeShowResponse(command, resp)
+}
+
+// showRows runs a SHOW statement and returns the rows of all of its series,
+// for statements such as SHOW USERS that report a single series.
+func showRows(conn *queryClient, command string) ([]showRow, error) {
+	series, err := show(conn, command)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []showRow
+	for _, s := range series {
+		rows = append(rows, s.rows...)
+	}
+	return rows, nil
+}
+
+func decodeShowResponse(command string, resp *client.Response) ([]showSeries, error) {
+	if len(resp.Results) != 1 {
+		return nil, fmt.Errorf("unexpected response to %s: expected 1 result, got %d", command, len(resp.Results))
+	}
+
+	var series []showSeries
+	for _, s := range resp.Results[0].Series {
+		decoded := showSeries{name: s.Name}
+		for i, values := range s.Values {
+			if len(values) != len(s.Columns) {
+				return nil, fmt.Errorf("unexpected response to %s: row %d has %d values for %d columns", command, i, len(values), len(s.Columns))
+			}
+			row := showRow{command: command, values: make(map[string]interface{}, len(values))}
+			for j, column := range s.Columns {
+				row.values[column] = values[j]
+			}
+			decoded.rows = append(decoded.rows, row)
+		}
+		series = append(series, decoded)
+	}
+	return series, nil
+}
+
+func (r showRow) value(column string) (interface{}, error) {
+	v, ok := r.values[column]
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to %s: no %q column", r.command, column)
+	}
+	return v, nil
+}
+
+func (r showRow) string(column string) (string, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", r.typeError(column, v, "a string")
+	}
+	return s, nil
+}
+
+func (r showRow) bool(column string) (bool, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, r.typeError(column, v, "a boolean")
+	}
+	return b, nil
+}
+
+func (r showRow) int(column string) (int, error) {
+	v, err := r.value(column)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i), nil
+		}
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	}
+	return 0, r.typeError(column, v, "an integer")
+}
+
+func (r showRow) typeError(column string, v interface{}, expected string) error {
+	got, err := json.Marshal(v)
+	if err != nil {
+		got = []byte(fmt.Sprint(v))
+	}
+	return fmt.Errorf("unexpected response to %s: expected %q to be %s, got %s", r.command, column, expected, got)
+}
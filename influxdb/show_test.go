@@ -0,0 +1,195 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newShowServer starts a server answering the statements in responses with
+// the corresponding response bodies.
+func newShowServer(responses map[string]json.RawMessage) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, ok := responses[r.FormValue("q")]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unexpected statement: " + r.FormValue("q")})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+}
+
+func showServerClient(srv *httptest.Server) *queryClient {
+	u, _ := url.Parse(srv.URL)
+	return testQueryClient(false, *u)
+}
+
+// TestShowFixtures decodes the SHOW output of several InfluxDB versions,
+// which differ in whether empty results have a series and in the columns
+// reported for retention policies.
+func TestShowFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/show/*.json")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("expected fixtures in testdata/show")
+	}
+
+	for _, fixture := range fixtures {
+		b, err := ioutil.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		var responses map[string]json.RawMessage
+		if err := json.Unmarshal(b, &responses); err != nil {
+			t.Fatalf("%s: %s", fixture, err)
+		}
+
+		srv := newShowServer(responses)
+		testShowFixture(t, fixture, showServerClient(srv))
+		srv.Close()
+	}
+}
+
+func testShowFixture(t *testing.T, fixture string, conn *queryClient) {
+	databases, err := listDatabases(conn)
+	if err != nil {
+		t.Errorf("%s: %s", fixture, err)
+	} else if expected := []string{"_internal", "telegraf"}; !reflect.DeepEqual(databases, expected) {
+		t.Errorf("%s: expected databases %q, got %q", fixture, expected, databases)
+	}
+
+	policies, err := listRetentionPolicies(conn, "telegraf")
+	if err != nil {
+		t.Errorf("%s: %s", fixture, err)
+	} else if expected := []retentionPolicy{
+		{name: "autogen", duration: "0s", shardGroupDuration: "168h0m0s", replication: 1, isDefault: false},
+		{name: "one_week", duration: "168h0m0s", shardGroupDuration: "24h0m0s", replication: 1, isDefault: true},
+	}; !reflect.DeepEqual(policies, expected) {
+		t.Errorf("%s: expected retention policies %+v, got %+v", fixture, expected, policies)
+	}
+
+	// A missing database has no policies.
+	if policies, err := listRetentionPolicies(conn, "missing"); err != nil || len(policies) != 0 {
+		t.Errorf("%s: expected no retention policies, got %+v, %v", fixture, policies, err)
+	}
+
+	users, err := listUsers(conn)
+	if err != nil {
+		t.Errorf("%s: %s", fixture, err)
+	} else if expected := []influxUser{{name: "admin", admin: true}, {name: "reader", admin: false}}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("%s: expected users %+v, got %+v", fixture, expected, users)
+	}
+
+	grants := map[string]map[string]string{
+		"reader": {"telegraf": "READ"},
+		"admin":  {},
+	}
+	for user, expected := range grants {
+		privileges, err := listGrants(conn, user)
+		if err != nil {
+			t.Errorf("%s: %s", fixture, err)
+		} else if !reflect.DeepEqual(privileges, expected) {
+			t.Errorf("%s: expected the privileges of %s to be %v, got %v", fixture, user, expected, privileges)
+		}
+	}
+	if _, err := listGrants(conn, "ghost"); err == nil || err.Error() != "user not found" {
+		t.Errorf("%s: expected a user not found error, got %v", fixture, err)
+	}
+
+	queries, err := listContinuousQueries(conn, "telegraf")
+	if err != nil {
+		t.Errorf("%s: %s", fixture, err)
+	} else if statement := queries["cpu_1h"]; len(queries) != 1 || !strings.HasPrefix(statement, "CREATE CONTINUOUS QUERY cpu_1h ON telegraf ") {
+		t.Errorf("%s: expected the cpu_1h continuous query, got %q", fixture, queries)
+	}
+	if queries, err := listContinuousQueries(conn, "_internal"); err != nil || len(queries) != 0 {
+		t.Errorf("%s: expected no continuous queries, got %q, %v", fixture, queries, err)
+	}
+}
+
+func TestShowErrors(t *testing.T) {
+	cases := []struct {
+		command  string
+		response string
+		list     func(conn *queryClient) error
+		expected string
+	}{
+		{
+			command:  "SHOW USERS",
+			response: `{"results":[]}`,
+			list:     func(conn *queryClient) error { _, err := listUsers(conn); return err },
+			expected: `unexpected response to SHOW USERS: expected 1 result, got 0`,
+		},
+		{
+			command:  "SHOW USERS",
+			response: `{"results":[{"series":[{"columns":["user"],"values":[["admin"]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listUsers(conn); return err },
+			expected: `unexpected response to SHOW USERS: no "admin" column`,
+		},
+		{
+			command:  "SHOW USERS",
+			response: `{"results":[{"series":[{"columns":["user","admin"],"values":[["admin","true"]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listUsers(conn); return err },
+			expected: `unexpected response to SHOW USERS: expected "admin" to be a boolean, got "true"`,
+		},
+		{
+			command:  "SHOW USERS",
+			response: `{"results":[{"series":[{"columns":["user","admin"],"values":[["admin"]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listUsers(conn); return err },
+			expected: `unexpected response to SHOW USERS: row 0 has 1 values for 2 columns`,
+		},
+		{
+			command:  "SHOW DATABASES",
+			response: `{"results":[{"series":[{"name":"databases","columns":["name"],"values":[[null]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listDatabases(conn); return err },
+			expected: `unexpected response to SHOW DATABASES: expected "name" to be a string, got null`,
+		},
+		{
+			command:  `SHOW RETENTION POLICIES ON "telegraf"`,
+			response: `{"results":[{"series":[{"columns":["name","duration","shardGroupDuration","replicaN","default"],"values":[["autogen","0s","168h0m0s",1.5,true]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listRetentionPolicies(conn, "telegraf"); return err },
+			expected: `unexpected response to SHOW RETENTION POLICIES ON "telegraf": expected "replicaN" to be an integer, got 1.5`,
+		},
+		{
+			command:  `SHOW RETENTION POLICIES ON "telegraf"`,
+			response: `{"results":[{"error":"error authorizing query: reader not authorized to execute statement 'SHOW RETENTION POLICIES ON telegraf', requires admin privilege"}]}`,
+			list:     func(conn *queryClient) error { _, err := listRetentionPolicies(conn, "telegraf"); return err },
+			expected: `error authorizing query: reader not authorized to execute statement 'SHOW RETENTION POLICIES ON telegraf', requires admin privilege`,
+		},
+		{
+			command:  `SHOW GRANTS FOR "reader"`,
+			response: `{"results":[{"series":[{"columns":["database"],"values":[["telegraf"]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listGrants(conn, "reader"); return err },
+			expected: `unexpected response to SHOW GRANTS FOR "reader": no "privilege" column`,
+		},
+		{
+			command:  "SHOW CONTINUOUS QUERIES",
+			response: `{"results":[{"series":[{"name":"telegraf","columns":["name","query"],"values":[["cpu_1h",1]]}]}]}`,
+			list:     func(conn *queryClient) error { _, err := listContinuousQueries(conn, "telegraf"); return err },
+			expected: `unexpected response to SHOW CONTINUOUS QUERIES: expected "query" to be a string, got 1`,
+		},
+	}
+
+	for _, c := range cases {
+		srv := newShowServer(map[string]json.RawMessage{c.command: json.RawMessage(c.response)})
+		err := c.list(showServerClient(srv))
+		srv.Close()
+
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("%s: expected error %q, got %v", c.response, c.expected, err)
+		}
+	}
+}
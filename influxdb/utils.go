@@ -3,8 +3,189 @@ package influxdb
 import (
 	"crypto/sha256"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
 )
 
+var durationPartRegexp = regexp.MustCompile(`^([0-9]+)(ns|u|µ|ms|s|m|h|d|w)`)
+
 func hashSum(contents interface{}) string {
 	return fmt.Sprintf("%x", sha256.Sum256([]byte(contents.(string))))
 }
+
+// parseDuration parses an InfluxQL duration literal such as "52w" or "1h30m".
+// It also accepts the Go formatting returned by SHOW RETENTION POLICIES
+// (e.g. "168h0m0s") and the special value "INF".
+func parseDuration(s string) (time.Duration, error) {
+	if s == "INF" || s == "inf" || s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration: empty string")
+	}
+
+	var d time.Duration
+	for rest := s; rest != ""; {
+		m := durationPartRegexp.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %s", s, err)
+		}
+
+		var unit time.Duration
+		switch m[2] {
+		case "ns":
+			unit = time.Nanosecond
+		case "u", "µ":
+			unit = time.Microsecond
+		case "ms":
+			unit = time.Millisecond
+		case "s":
+			unit = time.Second
+		case "m":
+			unit = time.Minute
+		case "h":
+			unit = time.Hour
+		case "d":
+			unit = day
+		case "w":
+			unit = week
+		}
+		d += time.Duration(n) * unit
+		rest = rest[len(m[0]):]
+	}
+
+	return d, nil
+}
+
+// formatDuration formats a duration the way InfluxDB does when it rewrites
+// statements, using the largest unit that divides the duration evenly.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "0s"
+	case d%week == 0:
+		return fmt.Sprintf("%dw", d/week)
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	default:
+		return fmt.Sprintf("%du", d/time.Microsecond)
+	}
+}
+
+// normalizeDuration rewrites a duration reported by the server (e.g.
+// "168h0m0s") as an InfluxQL literal (e.g. "1w"), leaving it untouched if it
+// cannot be parsed.
+func normalizeDuration(s string) string {
+	d, err := parseDuration(s)
+	if err != nil {
+		return s
+	}
+	if d == 0 {
+		return "INF"
+	}
+	return formatDuration(d)
+}
+
+// suppressEquivalentDurations suppresses diffs between two spellings of the
+// same duration, such as "1d" in the configuration and "24h0m0s" as reported
+// by the server.
+func suppressEquivalentDurations(k, old, new string, d *schema.ResourceData) bool {
+	if old == new {
+		return true
+	}
+	o, err := parseDuration(old)
+	if err != nil {
+		return false
+	}
+	n, err := parseDuration(new)
+	if err != nil {
+		return false
+	}
+	return o == n
+}
+
+// parseDatabaseScopedID splits the "<database>/<name>" IDs used to import
+// objects that belong to a database.
+func parseDatabaseScopedID(id string) (database, name string, err error) {
+	i := strings.LastIndex(id, "/")
+	if i <= 0 || i == len(id)-1 {
+		return "", "", fmt.Errorf("invalid ID %q, expected <database>/<name>", id)
+	}
+	return id[:i], id[i+1:], nil
+}
+
+// resolveDatabaseScopedID splits a "<database>/<name>" import ID where
+// either part may contain a "/", trying each split from the last "/" and
+// returning the first one for which exists finds the object. If there is
+// none it falls back to the last "/", leaving the read to report it missing.
+func resolveDatabaseScopedID(id string, exists func(database, name string) (bool, error)) (database, name string, err error) {
+	database, name, err = parseDatabaseScopedID(id)
+	if err != nil {
+		return "", "", err
+	}
+	for i := len(database); i > 0; i = strings.LastIndex(id[:i], "/") {
+		ok, err := exists(id[:i], id[i+1:])
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			return id[:i], id[i+1:], nil
+		}
+	}
+	return database, name, nil
+}
+
+func validateDuration(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := parseDuration(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be an InfluxQL duration such as 30m or 1h: %s", k, err))
+	}
+	return
+}
+
+// checkDuration makes sure a duration can be pasted into a statement, as
+// durations can't be quoted like identifiers and strings. Empty durations are
+// left out of statements.
+func checkDuration(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := parseDuration(s)
+	return err
+}
+
+// validateOptionalDuration is validateDuration for durations that may be left
+// empty for the server to choose.
+func validateOptionalDuration(v interface{}, k string) (ws []string, errors []error) {
+	if v.(string) == "" {
+		return
+	}
+	return validateDuration(v, k)
+}
+
+func validateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid regular expression: %s", k, err))
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"1d":        24 * time.Hour,
+		"52w":       52 * 7 * 24 * time.Hour,
+		"1h30m":     90 * time.Minute,
+		"168h0m0s":  168 * time.Hour,
+		"500ms":     500 * time.Millisecond,
+		"INF":       0,
+		"0s":        0,
+		"2016h0m0s": 12 * 7 * 24 * time.Hour,
+	}
+
+	for s, expected := range cases {
+		actual, err := parseDuration(s)
+		if err != nil {
+			t.Fatalf("err parsing %q: %s", s, err)
+		}
+		if actual != expected {
+			t.Errorf("expected %q to parse as %s, got %s", s, expected, actual)
+		}
+	}
+
+	for _, s := range []string{"", "1", "1y", "h", "1h 30m"} {
+		if _, err := parseDuration(s); err == nil {
+			t.Errorf("expected an error parsing %q", s)
+		}
+	}
+}
+
+func TestNormalizeDuration(t *testing.T) {
+	cases := map[string]string{
+		"24h0m0s":   "1d",
+		"168h0m0s":  "1w",
+		"1h0m0s":    "1h",
+		"1h30m0s":   "90m",
+		"0s":        "INF",
+		"not-valid": "not-valid",
+	}
+
+	for s, expected := range cases {
+		if actual := normalizeDuration(s); actual != expected {
+			t.Errorf("expected %q to normalize to %q, got %q", s, expected, actual)
+		}
+	}
+}
+
+func TestValidateDuration(t *testing.T) {
+	for _, v := range []string{"1d", "52w", "1h30m", "168h0m0s", "INF"} {
+		if _, errs := validateDuration(v, "duration"); len(errs) != 0 {
+			t.Errorf("expected %q to be valid, got %v", v, errs)
+		}
+	}
+	for _, v := range []string{"", "1 week", "1d REPLICATION 3", "1h; DROP DATABASE telegraf"} {
+		if _, errs := validateDuration(v, "duration"); len(errs) == 0 {
+			t.Errorf("expected %q to be invalid", v)
+		}
+	}
+
+	if _, errs := validateOptionalDuration("", "shard_duration"); len(errs) != 0 {
+		t.Errorf("expected an empty optional duration to be valid, got %v", errs)
+	}
+	if _, errs := validateOptionalDuration("1 week", "shard_duration"); len(errs) == 0 {
+		t.Error("expected \"1 week\" to be invalid")
+	}
+}